@@ -0,0 +1,266 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	mw "github.com/labstack/echo/v4/middleware"
+)
+
+// Access log formats.
+const (
+	// AccessLogCommonFormat is the NCSA Common Log Format.
+	AccessLogCommonFormat = `@remote_ip - - [@time_local] "@method @uri @protocol" @status @bytes_out`
+
+	// AccessLogCombinedFormat is the NCSA Combined Log Format.
+	AccessLogCombinedFormat = AccessLogCommonFormat + ` "@referer" "@user_agent"`
+)
+
+// Access log constants.
+const (
+	logTimeLocal     = "@time_local"
+	accessLogTimeFmt = "02/Jan/2006:15:04:05 -0700"
+)
+
+// AccessLogConfig defines the config for AccessLog middleware.
+type AccessLogConfig struct {
+	// Format defines the log line format, the tags are replaced by the
+	// request data.
+	//
+	// Tags to constructed the log line, besides the ones supported by the
+	// FieldMap of log middlewares (e.g. @remote_ip, @status, @header:<NAME>).
+	//
+	// - @time_local (Request start time in Common Log Format)
+	//
+	// Default: AccessLogCombinedFormat.
+	Format string
+
+	// Output defines the writer where the log lines are written.
+	// Default: os.Stdout.
+	Output io.Writer
+
+	// Redactor masks sensitive values of headers, query params, form fields
+	// and cookies, including the query params of @uri and @referer.
+	Redactor *Redactor
+
+	// IPExtractor defines how the client IP of @remote_ip is extracted, by
+	// default the echo.Context RealIP is used.
+	IPExtractor echo.IPExtractor
+
+	// Async writes the log lines in background workers, by default they are
+	// written synchronously.
+	Async *AsyncLogger
+
+	// Recover recovers from panics in the handler chain, writing the log
+	// line of the request. The 500 response is sent by the echo error
+	// handler.
+	Recover bool
+
+	// ErrorPolicy defines how the handler error is handled and propagated.
+	// Default: ErrorHandleAndPropagate.
+	ErrorPolicy ErrorPolicy
+
+	// Skipper defines a function to skip middleware.
+	Skipper mw.Skipper
+}
+
+// DefaultAccessLogConfig is the default AccessLog middleware config.
+var DefaultAccessLogConfig = AccessLogConfig{
+	Format:  AccessLogCombinedFormat,
+	Output:  os.Stdout,
+	Skipper: mw.DefaultSkipper,
+}
+
+// AccessLog returns a middleware that writes HTTP requests in NCSA Combined
+// Log Format.
+func AccessLog() echo.MiddlewareFunc {
+	return AccessLogWithConfig(DefaultAccessLogConfig)
+}
+
+// AccessLogWithConfig returns an AccessLog middleware with config.
+// See: `AccessLog()`.
+func AccessLogWithConfig(cfg AccessLogConfig) echo.MiddlewareFunc {
+	// Defaults
+	if cfg.Skipper == nil {
+		cfg.Skipper = DefaultAccessLogConfig.Skipper
+	}
+
+	if cfg.Format == "" {
+		cfg.Format = DefaultAccessLogConfig.Format
+	}
+
+	if cfg.Output == nil {
+		cfg.Output = DefaultAccessLogConfig.Output
+	}
+
+	segments := parseAccessLogFormat(cfg.Format)
+	opts := logOptions{
+		fieldMap:     map[string]string{},
+		redactor:     cfg.Redactor,
+		recoverPanic: cfg.Recover,
+		errorPolicy:  cfg.ErrorPolicy,
+		ipExtractor:  cfg.IPExtractor,
+	}
+
+	for _, s := range segments {
+		if s.tag != "" && s.tag != logTimeLocal {
+			opts.fieldMap[s.tag] = s.tag
+		}
+	}
+
+	var mu sync.Mutex
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ec echo.Context) (err error) {
+			if cfg.Skipper(ec) {
+				return next(ec)
+			}
+
+			start := time.Now()
+			entry, err := mapFields(ec, next, opts)
+			line := accessLogLine(segments, entry.fields, start)
+
+			cfg.Async.emit(func() {
+				mu.Lock()
+				_, _ = io.WriteString(cfg.Output, line)
+				mu.Unlock()
+			})
+
+			return
+		}
+	}
+}
+
+// parseAccessLogFormat splits the format into literal texts and tags.
+func parseAccessLogFormat(format string) []templateSegment {
+	segments := []templateSegment{}
+
+	for format != "" {
+		i := strings.IndexByte(format, '@')
+		if i < 0 {
+			segments = append(segments, templateSegment{text: format})
+			break
+		}
+
+		n := tagLen(format[i:])
+		if n == 1 {
+			segments = append(segments, templateSegment{text: format[:i+1]})
+			format = format[i+1:]
+
+			continue
+		}
+
+		if i > 0 {
+			segments = append(segments, templateSegment{text: format[:i]})
+		}
+
+		segments = append(segments, templateSegment{tag: format[i : i+n]})
+		format = format[i+n:]
+	}
+
+	return segments
+}
+
+// tagLen returns the length of the tag at the beginning of the string, the
+// name of prefixed tags (e.g. @header:<NAME>) is included.
+func tagLen(s string) int {
+	n := 1
+	for n < len(s) && isTagChar(s[n]) {
+		n++
+	}
+
+	// multi-value prefixes (e.g. @header[]:<NAME>)
+	if n > 1 && strings.HasPrefix(s[n:], "[]:") {
+		n += 2
+	}
+
+	if n > 1 && n+1 < len(s) && s[n] == ':' && isTagNameChar(s[n+1]) {
+		n++
+		for n < len(s) && isTagNameChar(s[n]) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// isTagChar checks if the char is allowed in tags.
+func isTagChar(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9')
+}
+
+// isTagNameChar checks if the char is allowed in the name of prefixed tags.
+func isTagNameChar(c byte) bool {
+	return isTagChar(c) || c == '-' || c == '.' || ('A' <= c && c <= 'Z')
+}
+
+// accessLogLine renders the log line with the field values.
+func accessLogLine(segments []templateSegment, fields map[string]interface{}, start time.Time) string {
+	var b strings.Builder
+
+	for _, s := range segments {
+		switch s.tag {
+		case "":
+			b.WriteString(s.text)
+		case logTimeLocal:
+			b.WriteString(start.Format(accessLogTimeFmt))
+		default:
+			b.WriteString(accessLogValue(s.tag, fields[s.tag]))
+		}
+	}
+
+	b.WriteByte('\n')
+
+	return b.String()
+}
+
+// accessLogValue formats the field value, empty values are replaced by "-"
+// and special chars are escaped.
+func accessLogValue(tag string, v interface{}) string {
+	if v == nil {
+		return templateEmpty
+	}
+
+	s := fmt.Sprint(v)
+	if values, ok := v.([]string); ok {
+		s = strings.Join(values, ", ")
+	}
+
+	if s == "" || (tag == logBytesOut && s == "0") {
+		return templateEmpty
+	}
+
+	return accessLogEscape(s)
+}
+
+// accessLogEscape escapes quotes, backslashes and non-printable chars, as
+// Apache HTTP server does.
+func accessLogEscape(s string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, `\x%02x`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestAccessLogWithConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		ec     echo.Context
+		format string
+		want   string
+	}{
+		{
+			"combined",
+			postCtx(t),
+			AccessLogCombinedFormat,
+			`^http://foo\.bar - - \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "POST http://some/foo/456\?name=john HTTP/1\.1" 200 4 "http://foo\.bar" "cli-agent"\n$`,
+		},
+		{
+			"common",
+			reqCtx(t),
+			AccessLogCommonFormat,
+			`^192\.0\.2\.1 - - \[[^\]]+\] "GET /some HTTP/1\.1" 200 4\n$`,
+		},
+		{
+			"custom",
+			postCtx(t),
+			`@method @route user=@header:user session=@cookie:session @unknown a@ b`,
+			`^POST /foo/:id user=admin session=A1B2C3 - a@ b\n$`,
+		},
+		{
+			"multi_values",
+			multiValueCtx(t),
+			`@header[]:X-Forwarded-For`,
+			`^10\.0\.0\.1, 10\.0\.0\.2\n$`,
+		},
+		{
+			"empty_values",
+			reqCtx(t),
+			`"@referer" "@user_agent"`,
+			`^"-" "-"\n$`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := new(bytes.Buffer)
+
+			config := AccessLogConfig{
+				Format: tt.format,
+				Output: b,
+			}
+
+			_ = AccessLogWithConfig(config)(testHandler)(tt.ec)
+
+			if !regexp.MustCompile(tt.want).MatchString(b.String()) {
+				t.Errorf("invalid log line: '%s'", b.String())
+			}
+		})
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	ec := reqCtx(t)
+	_ = AccessLog()(testHandler)(ec)
+}
+
+func TestAccessLogWithEmptyConfig(t *testing.T) {
+	ec := reqCtx(t)
+	_ = AccessLogWithConfig(AccessLogConfig{})(testHandler)(ec)
+}
+
+func TestAccessLogWithSkipper(t *testing.T) {
+	ec := reqCtx(t)
+	b := new(bytes.Buffer)
+
+	config := AccessLogConfig{
+		Output: b,
+		Skipper: func(echo.Context) bool {
+			return true
+		},
+	}
+
+	_ = AccessLogWithConfig(config)(testHandler)(ec)
+
+	if b.Len() != 0 {
+		t.Errorf("expect no log line, got '%s'", b.String())
+	}
+}
+
+func TestAccessLogWithNoContent(t *testing.T) {
+	ec := reqCtx(t)
+	b := new(bytes.Buffer)
+
+	config := AccessLogConfig{
+		Format: "@status @bytes_out",
+		Output: b,
+	}
+
+	_ = AccessLogWithConfig(config)(func(ec echo.Context) error {
+		return ec.NoContent(http.StatusNoContent)
+	})(ec)
+
+	if b.String() != "204 -\n" {
+		t.Errorf("invalid log line: '%s'", b.String())
+	}
+}
+
+func TestAccessLogEscape(t *testing.T) {
+	got := accessLogEscape("say \"hi\"\\\n\x7f")
+	want := `say \"hi\"\\\x0a\x7f`
+
+	if got != want {
+		t.Errorf("expect '%s', got '%s'", want, got)
+	}
+}
+
+func TestAccessLogWithRedactor(t *testing.T) {
+	ec := testCtx(t, "/some?access_token=secret&name=john")
+	ec.Request().Header.Set("Referer", "http://foo.bar/?access_token=secret")
+
+	b := new(bytes.Buffer)
+
+	config := AccessLogConfig{
+		Format:   "@uri @referer",
+		Output:   b,
+		Redactor: NewRedactor(RedactRule{Keys: []string{"access_token"}}),
+	}
+
+	_ = AccessLogWithConfig(config)(testHandler)(ec)
+
+	want := "/some?access_token=%5BREDACTED%5D&name=john http://foo.bar/?access_token=%5BREDACTED%5D\n"
+	if b.String() != want {
+		t.Errorf("invalid log line: '%s'", b.String())
+	}
+}
+
+func TestAccessLogWithRecover(t *testing.T) {
+	ec := panicCtx(t)
+	b := new(bytes.Buffer)
+
+	config := AccessLogConfig{
+		Format:  "@method @path @status",
+		Output:  b,
+		Recover: true,
+	}
+
+	if err := AccessLogWithConfig(config)(testHandler)(ec); err != nil {
+		t.Errorf("invalid log: expect panic to be handled, got '%v'", err)
+	}
+
+	if b.String() != "GET /some 500\n" {
+		t.Errorf("invalid log line: '%s'", b.String())
+	}
+}
+
+func TestAccessLogWithErrorPolicy(t *testing.T) {
+	testErrorPolicies(t, func(p ErrorPolicy) echo.MiddlewareFunc {
+		return AccessLogWithConfig(AccessLogConfig{Output: new(bytes.Buffer), ErrorPolicy: p})
+	})
+}
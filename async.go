@@ -0,0 +1,191 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Async logger constants.
+const (
+	defaultAsyncQueueSize = 1024
+	defaultAsyncWorkers   = 1
+)
+
+// OverflowPolicy defines the behavior when the async queue is full.
+type OverflowPolicy int
+
+// Overflow policies.
+const (
+	// OverflowBlock blocks the request until there is room in the queue.
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowDropNewest drops the entry being queued.
+	OverflowDropNewest
+
+	// OverflowDropOldest drops the oldest queued entry, making room for the
+	// new one.
+	OverflowDropOldest
+)
+
+// AsyncConfig defines the config for AsyncLogger.
+type AsyncConfig struct {
+	// QueueSize defines the max number of queued log entries.
+	// Default: 1024.
+	QueueSize int
+
+	// Workers defines the number of background workers emitting the log
+	// entries. Default: 1.
+	Workers int
+
+	// Overflow defines the behavior when the queue is full.
+	// Default: OverflowBlock.
+	Overflow OverflowPolicy
+}
+
+// AsyncLogger emits the log entries in background workers, so a slow log
+// sink does not add latency to the responses. It must be closed on shutdown,
+// entries received after closing are emitted synchronously.
+type AsyncLogger struct {
+	queue    chan func()
+	overflow OverflowPolicy
+	workers  sync.WaitGroup
+	dropped  atomic.Uint64
+
+	mu      sync.Mutex
+	drained *sync.Cond
+	pending int
+
+	closeMu sync.RWMutex
+	closed  bool
+}
+
+// NewAsyncLogger returns an async logger with its workers started.
+func NewAsyncLogger(cfg AsyncConfig) *AsyncLogger {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultAsyncQueueSize
+	}
+
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaultAsyncWorkers
+	}
+
+	a := &AsyncLogger{
+		queue:    make(chan func(), cfg.QueueSize),
+		overflow: cfg.Overflow,
+	}
+
+	a.drained = sync.NewCond(&a.mu)
+	a.workers.Add(cfg.Workers)
+
+	for i := 0; i < cfg.Workers; i++ {
+		go a.work()
+	}
+
+	return a
+}
+
+// Dropped returns the number of log entries dropped by the overflow policy.
+func (a *AsyncLogger) Dropped() uint64 {
+	return a.dropped.Load()
+}
+
+// Flush waits until every queued log entry is emitted.
+func (a *AsyncLogger) Flush() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for a.pending > 0 {
+		a.drained.Wait()
+	}
+}
+
+// Close emits the queued log entries and stops the workers.
+func (a *AsyncLogger) Close() error {
+	a.closeMu.Lock()
+
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+
+	a.closeMu.Unlock()
+	a.workers.Wait()
+
+	return nil
+}
+
+// emit queues the log function, it runs synchronously when there is no async
+// logger or it is closed.
+func (a *AsyncLogger) emit(fn func()) {
+	if a == nil {
+		fn()
+		return
+	}
+
+	a.closeMu.RLock()
+	defer a.closeMu.RUnlock()
+
+	if a.closed {
+		fn()
+		return
+	}
+
+	a.add(1)
+
+	switch a.overflow {
+	case OverflowDropNewest:
+		select {
+		case a.queue <- fn:
+		default:
+			a.drop()
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case a.queue <- fn:
+				return
+			default:
+			}
+
+			select {
+			case <-a.queue:
+				a.drop()
+			default:
+			}
+		}
+	default:
+		a.queue <- fn
+	}
+}
+
+// work emits the queued log entries until the queue is closed.
+func (a *AsyncLogger) work() {
+	defer a.workers.Done()
+
+	for fn := range a.queue {
+		fn()
+		a.add(-1)
+	}
+}
+
+// drop counts a dropped log entry.
+func (a *AsyncLogger) drop() {
+	a.dropped.Add(1)
+	a.add(-1)
+}
+
+// add updates the number of pending entries, notifying when it is drained.
+func (a *AsyncLogger) add(delta int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.pending += delta
+	if a.pending == 0 {
+		a.drained.Broadcast()
+	}
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// blockAsync returns an async logger with its single worker blocked until
+// the returned function is called.
+func blockAsync(t *testing.T, overflow OverflowPolicy) (*AsyncLogger, func()) {
+	t.Helper()
+
+	a := NewAsyncLogger(AsyncConfig{QueueSize: 1, Overflow: overflow})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	a.emit(func() {
+		close(started)
+		<-release
+	})
+
+	<-started
+
+	return a, func() { close(release) }
+}
+
+func TestAsyncLoggerWithoutLogger(t *testing.T) {
+	var a *AsyncLogger
+
+	called := false
+	a.emit(func() { called = true })
+
+	if !called {
+		t.Errorf("expect log function called synchronously")
+	}
+}
+
+func TestAsyncLoggerFlush(t *testing.T) {
+	a := NewAsyncLogger(AsyncConfig{Workers: 4})
+	defer a.Close()
+
+	var (
+		mu    sync.Mutex
+		count int
+	)
+
+	for i := 0; i < 100; i++ {
+		a.emit(func() {
+			mu.Lock()
+			count++
+			mu.Unlock()
+		})
+	}
+
+	a.Flush()
+
+	if count != 100 {
+		t.Errorf("expect 100 entries emitted, got '%d'", count)
+	}
+}
+
+func TestAsyncLoggerOverflow(t *testing.T) {
+	tests := []struct {
+		name     string
+		overflow OverflowPolicy
+		want     []int
+	}{
+		{"drop_newest", OverflowDropNewest, []int{1}},
+		{"drop_oldest", OverflowDropOldest, []int{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, release := blockAsync(t, tt.overflow)
+
+			var emitted []int
+
+			for i := 1; i <= 3; i++ {
+				i := i
+				a.emit(func() { emitted = append(emitted, i) })
+			}
+
+			release()
+			_ = a.Close()
+
+			if !slices.Equal(emitted, tt.want) {
+				t.Errorf("expect entries '%v' emitted, got '%v'", tt.want, emitted)
+			}
+
+			if a.Dropped() != 2 {
+				t.Errorf("expect 2 dropped entries, got '%d'", a.Dropped())
+			}
+		})
+	}
+}
+
+func TestAsyncLoggerClose(t *testing.T) {
+	a := NewAsyncLogger(AsyncConfig{})
+
+	count := 0
+	a.emit(func() { count++ })
+
+	if err := a.Close(); err != nil {
+		t.Errorf("expect no error, got '%v'", err)
+	}
+
+	if count != 1 {
+		t.Errorf("expect queued entries emitted on close, got '%d'", count)
+	}
+
+	a.emit(func() { count++ })
+
+	if count != 2 {
+		t.Errorf("expect entries emitted synchronously after close, got '%d'", count)
+	}
+
+	_ = a.Close()
+}
+
+func TestZapLogWithAsync(t *testing.T) {
+	logger, logs := observer.New(zap.InfoLevel)
+	async := NewAsyncLogger(AsyncConfig{})
+
+	config := ZapLogConfig{
+		Logger: zap.New(logger),
+		Async:  async,
+	}
+
+	_ = ZapLogWithConfig(config)(testHandler)(reqCtx(t))
+
+	async.Flush()
+
+	if logs.Len() != 1 {
+		t.Errorf("expect 1 entry emitted, got '%d'", logs.Len())
+	}
+
+	_ = async.Close()
+}
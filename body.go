@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Body capture constants.
+const (
+	defaultBodyLimit = 1024
+	bodyTruncated    = "...[truncated]"
+)
+
+// BodyConfig defines the config to capture the request or response body.
+type BodyConfig struct {
+	// Limit defines the max number of bytes captured, the remaining content
+	// is discarded and the value is marked as truncated. Default: 1024.
+	Limit int
+
+	// ContentTypes defines the list of media types allowed to be captured,
+	// a value ending with "/" matches any subtype (e.g. "text/").
+	// Default: application/json, application/x-www-form-urlencoded and text/.
+	ContentTypes []string
+
+	// ErrorsOnly restricts the capture to responses with status code 4xx and
+	// 5xx, it is only applied to the response body.
+	ErrorsOnly bool
+}
+
+// DefaultBodyConfig is the default body capture config.
+var DefaultBodyConfig = BodyConfig{
+	Limit: defaultBodyLimit,
+	ContentTypes: []string{
+		echo.MIMEApplicationJSON,
+		echo.MIMEApplicationForm,
+		"text/",
+	},
+}
+
+// bodyConfig fills the body config with default values.
+func bodyConfig(cfg BodyConfig) BodyConfig {
+	if cfg.Limit <= 0 {
+		cfg.Limit = DefaultBodyConfig.Limit
+	}
+
+	if len(cfg.ContentTypes) == 0 {
+		cfg.ContentTypes = DefaultBodyConfig.ContentTypes
+	}
+
+	return cfg
+}
+
+// readCloser restores a partially consumed body keeping the original closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// captureRequestBody reads the request body up to the limit and restores it,
+// so the handler is able to consume the whole content. The fields of URL
+// encoded forms are masked by the redactor.
+func captureRequestBody(req *http.Request, cfg BodyConfig, r *Redactor) string {
+	if req.Body == nil || req.Body == http.NoBody {
+		return ""
+	}
+
+	ct := req.Header.Get(echo.HeaderContentType)
+	if !allowedContentType(ct, cfg.ContentTypes) {
+		return ""
+	}
+
+	buf := make([]byte, cfg.Limit+1)
+	n, _ := io.ReadFull(req.Body, buf)
+	buf = buf[:n]
+
+	req.Body = &readCloser{
+		Reader: io.MultiReader(bytes.NewReader(buf), req.Body),
+		Closer: req.Body,
+	}
+
+	if r == nil || !allowedContentType(ct, []string{echo.MIMEApplicationForm}) {
+		return bodyValue(buf, cfg.Limit)
+	}
+
+	truncated := len(buf) > cfg.Limit
+	value := r.redactParams(RedactForm, string(buf[:min(len(buf), cfg.Limit)]))
+
+	if truncated {
+		value += bodyTruncated
+	}
+
+	return value
+}
+
+// bodyValue returns the captured content, marking it when the limit was
+// exceeded.
+func bodyValue(b []byte, limit int) string {
+	if len(b) > limit {
+		return string(b[:limit]) + bodyTruncated
+	}
+
+	return string(b)
+}
+
+// allowedContentType checks if the content type matches one of the allowed
+// media types.
+func allowedContentType(ct string, allowed []string) bool {
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+
+	for _, a := range allowed {
+		if strings.HasSuffix(a, "/") && strings.HasPrefix(mt, a) {
+			return true
+		}
+
+		if mt == a {
+			return true
+		}
+	}
+
+	return false
+}
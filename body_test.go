@@ -0,0 +1,83 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestCaptureRequestBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		limit       int
+		want        string
+	}{
+		{"json", echo.MIMEApplicationJSONCharsetUTF8, `{"name":"john"}`, 0, `{"name":"john"}`},
+		{"text", echo.MIMETextPlain, "hello", 0, "hello"},
+		{"truncated", echo.MIMETextPlain, "hello world", 5, "hello" + bodyTruncated},
+		{"exact_limit", echo.MIMETextPlain, "hello", 5, "hello"},
+		{"not_allowed", echo.MIMEOctetStream, "binary", 0, ""},
+		{"invalid_content_type", "", "data", 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(echo.POST, "/", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, tt.contentType)
+
+			got := captureRequestBody(req, bodyConfig(BodyConfig{Limit: tt.limit}), nil)
+			if got != tt.want {
+				t.Errorf("expect body '%s', got '%s'", tt.want, got)
+			}
+
+			restored, _ := io.ReadAll(req.Body)
+			if string(restored) != tt.body {
+				t.Errorf("expect restored body '%s', got '%s'", tt.body, restored)
+			}
+		})
+	}
+}
+
+func TestCaptureRequestBodyWithoutBody(t *testing.T) {
+	req := httptest.NewRequest(echo.GET, "/", nil)
+
+	if got := captureRequestBody(req, DefaultBodyConfig, nil); got != "" {
+		t.Errorf("expect empty body, got '%s'", got)
+	}
+}
+
+func TestCaptureRequestBodyWithRedactor(t *testing.T) {
+	r := NewRedactor(RedactRule{Keys: []string{"password"}})
+
+	tests := []struct {
+		name  string
+		ct    string
+		body  string
+		limit int
+		want  string
+	}{
+		{"form", echo.MIMEApplicationForm, "user=a&password=hunter2", 1024, "user=a&password=%5BREDACTED%5D"},
+		{"form truncated", echo.MIMEApplicationForm, "password=hunter2&user=a", 18, "password=%5BREDACTED%5D&u...[truncated]"},
+		{"other fields", echo.MIMEApplicationForm, "user=a", 1024, "user=a"},
+		{"json", echo.MIMEApplicationJSON, `{"user":"a"}`, 1024, `{"user":"a"}`},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(echo.POST, "/", strings.NewReader(tt.body))
+		req.Header.Set(echo.HeaderContentType, tt.ct)
+
+		if got := captureRequestBody(req, bodyConfig(BodyConfig{Limit: tt.limit}), r); got != tt.want {
+			t.Errorf("invalid %s: expect '%s', got '%s'", tt.name, tt.want, got)
+		}
+	}
+}
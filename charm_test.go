@@ -7,7 +7,9 @@ package middleware
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"strings"
 	"testing"
 
@@ -132,3 +134,120 @@ func TestCharmLogRecoverFn(t *testing.T) {
 		t.Errorf("invalid log: error not found")
 	}
 }
+
+func TestCharmLogWithLevel(t *testing.T) {
+	ec := errCtx(t)
+	b := new(bytes.Buffer)
+
+	config := CharmLogConfig{
+		Logger: charm.New(b),
+		Level:  StatusLevel,
+	}
+
+	_ = CharmLogWithConfig(config)(testHandler)(ec)
+
+	if !strings.Contains(b.String(), "ERRO") {
+		t.Errorf("invalid log: expect error level")
+	}
+}
+
+func TestCharmLogWithNestedFields(t *testing.T) {
+	ec := postCtx(t)
+	b := new(bytes.Buffer)
+
+	config := CharmLogConfig{
+		Logger: charm.New(b),
+		FieldMap: map[string]string{
+			"http.request.method": logMethod,
+		},
+		NestedFields: true,
+	}
+
+	_ = CharmLogWithConfig(config)(testHandler)(ec)
+
+	if !strings.Contains(b.String(), "http.request.method=POST") {
+		t.Errorf("invalid log: expect prefixed fields, got '%s'", b.String())
+	}
+}
+
+func TestCharmLogWithContextLogger(t *testing.T) {
+	ec := postCtx(t)
+	b := new(bytes.Buffer)
+
+	config := CharmLogConfig{
+		Logger:          charm.New(b),
+		ContextFieldMap: RequestContextFieldMap,
+	}
+
+	_ = CharmLogWithConfig(config)(func(ec echo.Context) error {
+		CharmLogFromContext(ec.Request().Context()).Info("handler")
+		return testHandler(ec)
+	})(ec)
+
+	line := strings.Split(b.String(), "\n")[0]
+
+	if !strings.Contains(line, "id=123") || !strings.Contains(line, "route=/foo/:id") {
+		t.Errorf("invalid log: expect request fields, got '%s'", line)
+	}
+}
+
+func TestCharmLogFromContextFallback(t *testing.T) {
+	if CharmLogFromContext(context.Background()) != DefaultCharmLogConfig.Logger {
+		t.Errorf("expect default config logger")
+	}
+}
+
+func TestCharmLogWithRecover(t *testing.T) {
+	ec := panicCtx(t)
+	b := new(bytes.Buffer)
+
+	config := CharmLogConfig{
+		Logger:  charm.New(b),
+		Recover: true,
+	}
+
+	_ = CharmLogWithConfig(config)(testHandler)(ec)
+
+	for _, s := range []string{"ERRO", recoverMessage, recoverStackField} {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("invalid log: expect '%s'", s)
+		}
+	}
+}
+
+func TestCharmLogWithMessage(t *testing.T) {
+	ec := postCtx(t)
+	b := new(bytes.Buffer)
+
+	config := CharmLogConfig{
+		Logger:  charm.New(b),
+		Message: "{method} {route} {status}",
+	}
+
+	_ = CharmLogWithConfig(config)(testHandler)(ec)
+
+	if !strings.Contains(b.String(), "POST /foo/:id 200") {
+		t.Errorf("invalid log: expect templated message, got '%s'", b.String())
+	}
+}
+
+func TestCharmLogWithErrorPolicy(t *testing.T) {
+	logger := charm.New(io.Discard)
+
+	testErrorPolicies(t, func(p ErrorPolicy) echo.MiddlewareFunc {
+		return CharmLogWithConfig(CharmLogConfig{Logger: logger, ErrorPolicy: p})
+	})
+}
+
+func TestCharmLogWithConfiguredContextLogger(t *testing.T) {
+	ec := postCtx(t)
+	logger := charm.New(io.Discard)
+
+	_ = CharmLogWithConfig(CharmLogConfig{Logger: logger})(func(ec echo.Context) error {
+		if CharmLogFromContext(ec.Request().Context()) != logger {
+			t.Errorf("invalid log: expect configured logger")
+		}
+
+		return testHandler(ec)
+	})(ec)
+}
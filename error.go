@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ErrorPolicy defines how the log middlewares handle the error returned by
+// the handler.
+type ErrorPolicy int
+
+// Error policies.
+const (
+	// ErrorHandleAndPropagate calls the echo error handler and returns the
+	// error to the previous middlewares.
+	ErrorHandleAndPropagate ErrorPolicy = iota
+
+	// ErrorHandleAndSwallow calls the echo error handler and returns nil, so
+	// the error is not handled again.
+	ErrorHandleAndSwallow
+
+	// ErrorPropagateOnly returns the error without calling the echo error
+	// handler, the logged status is the one written by the handler.
+	ErrorPropagateOnly
+
+	// ErrorResolve returns the error without calling the echo error handler,
+	// the logged status is resolved from the error (echo.HTTPError code or
+	// 500) when the response is not committed.
+	ErrorResolve
+)
+
+// handle applies the policy on the error, returning the error to be
+// propagated.
+func (p ErrorPolicy) handle(ec echo.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch p {
+	case ErrorHandleAndSwallow:
+		ec.Error(err)
+		return nil
+	case ErrorPropagateOnly:
+		return err
+	case ErrorResolve:
+		if res := ec.Response(); !res.Committed {
+			res.Status = errorStatus(err)
+		}
+
+		return err
+	default:
+		ec.Error(err)
+		return err
+	}
+}
+
+// errorStatus returns the HTTP status code of the error, echo.HTTPError code
+// or 500.
+func errorStatus(err error) int {
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		return he.Code
+	}
+
+	return http.StatusInternalServerError
+}
+
+// Name of the method providing the stack trace of errors (e.g. pkg/errors).
+const stackTraceMethod = "StackTrace"
+
+// errorTags sets the tags describing the error and its chain, the stack
+// trace is only resolved when it is requested by the field map.
+func errorTags(err error, tags map[string]interface{}, opts logOptions) {
+	tags[logError] = err
+	tags[logErrorType] = fmt.Sprintf("%T", err)
+	tags[logErrorChain] = errorChain(err)
+	tags[logErrorMessage] = err.Error()
+
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		tags[logErrorCode] = strconv.Itoa(he.Code)
+		if opts.typedValues {
+			tags[logErrorCode] = he.Code
+		}
+
+		tags[logErrorMessage] = fmt.Sprint(he.Message)
+
+		if he.Internal != nil {
+			tags[logErrorInternal] = he.Internal.Error()
+		}
+	}
+
+	if hasTag(opts.fieldMap, logErrorStack) {
+		if stack, ok := errorStack(err); ok {
+			tags[logErrorStack] = stack
+		}
+	}
+}
+
+// errorChain returns the messages of the error and the ones it wraps.
+func errorChain(err error) []string {
+	chain := []string{}
+
+	for ; err != nil; err = errors.Unwrap(err) {
+		chain = append(chain, err.Error())
+	}
+
+	return chain
+}
+
+// errorStack returns the stack trace of the first error in the chain
+// providing a StackTrace method, formatted with "%+v".
+func errorStack(err error) (string, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		m := reflect.ValueOf(err).MethodByName(stackTraceMethod)
+		if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+			continue
+		}
+
+		return fmt.Sprintf("%+v", m.Call(nil)[0].Interface()), true
+	}
+
+	return "", false
+}
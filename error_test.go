@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+var errorFields = map[string]string{
+	"error_code":     logErrorCode,
+	"error_message":  logErrorMessage,
+	"error_internal": logErrorInternal,
+	"error_type":     logErrorType,
+	"error_chain":    logErrorChain,
+	"error_stack":    logErrorStack,
+}
+
+type stackError struct{}
+
+func (stackError) Error() string { return "stack error" }
+
+func (stackError) StackTrace() []string {
+	return []string{"main.go:10", "handler.go:20"}
+}
+
+func TestMapFieldsWithHTTPError(t *testing.T) {
+	internal := fmt.Errorf("query users: %w", errors.New("connection refused"))
+	he := echo.NewHTTPError(http.StatusServiceUnavailable, "unavailable").SetInternal(internal)
+
+	handler := func(echo.Context) error { return he }
+	entry, _ := mapFields(reqCtx(t), handler, logOptions{fieldMap: errorFields})
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"error_code", entry.fields["error_code"], "503"},
+		{"error_message", entry.fields["error_message"], "unavailable"},
+		{"error_internal", entry.fields["error_internal"], "query users: connection refused"},
+		{"error_type", entry.fields["error_type"], "*echo.HTTPError"},
+		{"error_chain", entry.fields["error_chain"], []string{
+			he.Error(),
+			"query users: connection refused",
+			"connection refused",
+		}},
+	}
+
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("invalid %s: expect '%v', got '%v'", tt.name, tt.want, tt.got)
+		}
+	}
+
+	if _, ok := entry.fields["error_stack"]; ok {
+		t.Errorf("expect no error_stack, got '%v'", entry.fields["error_stack"])
+	}
+}
+
+func TestMapFieldsWithTypedErrorCode(t *testing.T) {
+	handler := func(echo.Context) error { return echo.ErrNotFound }
+	opts := logOptions{fieldMap: errorFields, typedValues: true}
+
+	entry, _ := mapFields(reqCtx(t), handler, opts)
+
+	if entry.fields["error_code"] != http.StatusNotFound {
+		t.Errorf("expect error_code as int, got '%T'", entry.fields["error_code"])
+	}
+}
+
+func TestMapFieldsWithErrorStack(t *testing.T) {
+	handler := func(echo.Context) error { return fmt.Errorf("handle: %w", stackError{}) }
+	entry, _ := mapFields(reqCtx(t), handler, logOptions{fieldMap: errorFields})
+
+	stack, _ := entry.fields["error_stack"].(string)
+	if !strings.Contains(stack, "handler.go:20") {
+		t.Errorf("expect error_stack, got '%v'", entry.fields["error_stack"])
+	}
+
+	if entry.fields["error_message"] != "handle: stack error" {
+		t.Errorf("expect error_message, got '%v'", entry.fields["error_message"])
+	}
+
+	if _, ok := entry.fields["error_code"]; ok {
+		t.Errorf("expect no error_code, got '%v'", entry.fields["error_code"])
+	}
+}
+
+func TestMapFieldsWithoutError(t *testing.T) {
+	entry, _ := mapFields(reqCtx(t), testHandler, logOptions{fieldMap: errorFields})
+
+	if len(entry.fields) != 0 {
+		t.Errorf("expect no error fields, got '%v'", entry.fields)
+	}
+}
+
+// testErrorPolicies checks every error policy with the middleware built by
+// the provided function.
+func testErrorPolicies(t *testing.T, middleware func(ErrorPolicy) echo.MiddlewareFunc) {
+	t.Helper()
+
+	handler := func(echo.Context) error { return echo.ErrNotFound }
+
+	tests := []struct {
+		name      string
+		policy    ErrorPolicy
+		err       error
+		committed bool
+		status    int
+	}{
+		{"handle and propagate", ErrorHandleAndPropagate, echo.ErrNotFound, true, http.StatusNotFound},
+		{"handle and swallow", ErrorHandleAndSwallow, nil, true, http.StatusNotFound},
+		{"propagate only", ErrorPropagateOnly, echo.ErrNotFound, false, 0},
+		{"resolve", ErrorResolve, echo.ErrNotFound, false, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		ec := reqCtx(t)
+		err := middleware(tt.policy)(handler)(ec)
+
+		if !errors.Is(err, tt.err) {
+			t.Errorf("invalid %s: expect error '%v', got '%v'", tt.name, tt.err, err)
+		}
+
+		if res := ec.Response(); res.Committed != tt.committed || res.Status != tt.status {
+			t.Errorf("invalid %s: expect committed '%v' and status '%d', got '%v' and '%d'",
+				tt.name, tt.committed, tt.status, res.Committed, res.Status)
+		}
+	}
+}
+
+func TestMapFieldsWithErrorResolve(t *testing.T) {
+	handler := func(echo.Context) error { return errors.New("failed") }
+	opts := logOptions{
+		fieldMap:    map[string]string{"status": logStatus},
+		level:       StatusLevel,
+		errorPolicy: ErrorResolve,
+	}
+
+	entry, err := mapFields(reqCtx(t), handler, opts)
+	if err == nil {
+		t.Errorf("expect error to be propagated")
+	}
+
+	if entry.fields["status"] != http.StatusInternalServerError || entry.level != LevelError {
+		t.Errorf("invalid entry: status '%v', level '%v'", entry.fields["status"], entry.level)
+	}
+}
+
+func TestErrorPolicyWithCommittedResponse(t *testing.T) {
+	handler := func(ec echo.Context) error {
+		_ = ec.NoContent(http.StatusAccepted)
+		return errors.New("failed")
+	}
+
+	ec := reqCtx(t)
+	_, _ = mapFields(ec, handler, logOptions{errorPolicy: ErrorResolve})
+
+	if status := ec.Response().Status; status != http.StatusAccepted {
+		t.Errorf("expect committed status to be kept, got '%d'", status)
+	}
+}
@@ -6,6 +6,8 @@
 package middleware_test
 
 import (
+	"log/slog"
+	"net/http"
 	"os"
 
 	charm "github.com/charmbracelet/log"
@@ -156,6 +158,41 @@ func ExampleZapLogWithConfig() {
 	e.Use(middleware.ZapLogWithConfig(logConfig))
 }
 
+// This example stores a request-scoped ZapLog logger into the request context,
+// so the handler logs carry the request id, route and remote ip.
+func ExampleZapLogFromContext() {
+	e := echo.New()
+
+	// Middleware
+	e.Use(middleware.ZapLogWithConfig(middleware.ZapLogConfig{
+		ContextFieldMap: middleware.RequestContextFieldMap,
+	}))
+
+	e.GET("/", func(ec echo.Context) error {
+		logger := middleware.ZapLogFromContext(ec.Request().Context())
+		logger.Info("handling the request")
+
+		return ec.NoContent(http.StatusNoContent)
+	})
+}
+
+// This example registers the ZapLog middleware emitting the log entries in
+// background workers, the async logger must be closed on shutdown.
+func ExampleNewAsyncLogger() {
+	e := echo.New()
+
+	async := middleware.NewAsyncLogger(middleware.AsyncConfig{
+		QueueSize: 4096,
+		Overflow:  middleware.OverflowDropOldest,
+	})
+	defer async.Close()
+
+	// Middleware
+	e.Use(middleware.ZapLogWithConfig(middleware.ZapLogConfig{
+		Async: async,
+	}))
+}
+
 // This example register the ZapLog log error function to echo middleware
 // Recover.
 func ExampleZapLogRecoverFn() {
@@ -207,6 +244,94 @@ func ExampleCharmLogRecoverFn() {
 	}))
 }
 
+// This example registers the Slog middleware with default configuration.
+func ExampleSlogLog() {
+	e := echo.New()
+
+	// Middleware
+	e.Use(middleware.SlogLog())
+}
+
+// This example registers the Slog middleware with custom configuration.
+func ExampleSlogLogWithConfig() {
+	e := echo.New()
+
+	// Custom slog logger instance
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	// Middleware
+	logConfig := middleware.SlogLogConfig{
+		Logger: logger,
+		FieldMap: map[string]string{
+			"uri":    "@uri",
+			"host":   "@host",
+			"method": "@method",
+			"status": "@status",
+		},
+	}
+
+	e.Use(middleware.SlogLogWithConfig(logConfig))
+}
+
+// This example register the Slog log error function to echo middleware
+// Recover.
+func ExampleSlogLogRecoverFn() {
+	e := echo.New()
+
+	// Custom slog logger instance
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	// Middleware
+	e.Use(emw.RecoverWithConfig(emw.RecoverConfig{
+		LogErrorFunc: middleware.SlogLogRecoverFn(logger),
+	}))
+}
+
+// This example registers a custom tag and a custom tag prefix, honored by the
+// FieldMap of every log middleware.
+func ExampleRegisterTag() {
+	e := echo.New()
+
+	middleware.RegisterTag("@tenant", func(ec echo.Context) interface{} {
+		return ec.Request().Header.Get("X-Tenant-ID")
+	})
+
+	middleware.RegisterTagPrefix("@claim:", func(ec echo.Context, name string) interface{} {
+		claims, _ := ec.Get("claims").(map[string]interface{})
+		return claims[name]
+	})
+
+	// Middleware
+	e.Use(middleware.ZapLogWithConfig(middleware.ZapLogConfig{
+		FieldMap: map[string]string{
+			"uri":    "@uri",
+			"tenant": "@tenant",
+			"user":   "@claim:sub",
+		},
+	}))
+}
+
+// This example registers the AccessLog middleware with default configuration.
+func ExampleAccessLog() {
+	e := echo.New()
+
+	// Middleware
+	e.Use(middleware.AccessLog())
+}
+
+// This example registers the AccessLog middleware with custom configuration.
+func ExampleAccessLogWithConfig() {
+	e := echo.New()
+
+	// Middleware
+	logConfig := middleware.AccessLogConfig{
+		Format: middleware.AccessLogCommonFormat,
+		Output: os.Stderr,
+	}
+
+	e.Use(middleware.AccessLogWithConfig(logConfig))
+}
+
 // This example registers the RequestID middleware with default configuration.
 func ExampleRequestID() {
 	e := echo.New()
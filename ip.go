@@ -0,0 +1,189 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RFC 7239 header and the parameter of the client address.
+const (
+	headerForwarded = "Forwarded"
+	forwardedFor    = "for"
+)
+
+// ExtractIPFromForwardedHeader returns an IP extractor based on the RFC 7239
+// Forwarded header. The hops are walked from the connection peer to the
+// client, returning the first address out of the trusted ranges; when a hop
+// is invalid (e.g. "unknown" or obfuscated) the peer address is returned.
+//
+// Default trusted ranges: loopback, link-local and private addresses.
+func ExtractIPFromForwardedHeader(trusted ...netip.Prefix) echo.IPExtractor {
+	return func(req *http.Request) string {
+		return rightmostUntrusted(req, forwardedHops(req.Header), trusted)
+	}
+}
+
+// ExtractIPFromXFFHeader returns an IP extractor based on the X-Forwarded-For
+// header, the rightmost address out of the trusted ranges is returned.
+//
+// Default trusted ranges: loopback, link-local and private addresses.
+func ExtractIPFromXFFHeader(trusted ...netip.Prefix) echo.IPExtractor {
+	return func(req *http.Request) string {
+		return rightmostUntrusted(req, xffHops(req.Header), trusted)
+	}
+}
+
+// ExtractIPFromHeader returns an IP extractor based on a header set by a
+// proxy with a single client address (e.g. "CF-Connecting-IP"). The header is
+// only used when the connection peer is trusted, otherwise the peer address
+// is returned.
+//
+// Default trusted ranges: loopback, link-local and private addresses.
+func ExtractIPFromHeader(name string, trusted ...netip.Prefix) echo.IPExtractor {
+	return func(req *http.Request) string {
+		peer := peerIP(req)
+
+		addr, ok := parseHop(req.Header.Get(name))
+		if !ok || !trustedIP(peer, trusted) {
+			return peer
+		}
+
+		return addr.String()
+	}
+}
+
+// remoteIP returns the client address using the extractor, when it is not
+// defined the echo.Context RealIP is used.
+func remoteIP(ec echo.Context, extract echo.IPExtractor) string {
+	if extract == nil {
+		return ec.RealIP()
+	}
+
+	return extract(ec.Request())
+}
+
+// forwardedChain returns the proxy hops of the request, from the Forwarded
+// header or X-Forwarded-For when it is not present.
+func forwardedChain(h http.Header) []string {
+	if hops := forwardedHops(h); len(hops) > 0 {
+		return hops
+	}
+
+	return xffHops(h)
+}
+
+// peerIP returns the address of the connection peer.
+func peerIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+
+	return host
+}
+
+// rightmostUntrusted walks the hops from the connection peer to the client,
+// returning the first address out of the trusted ranges. When every hop is
+// trusted the leftmost one is returned.
+func rightmostUntrusted(req *http.Request, hops []string, trusted []netip.Prefix) string {
+	peer := peerIP(req)
+	hops = append(hops, peer)
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parseHop(hops[i])
+		if !ok {
+			return peer
+		}
+
+		if !trustedIP(addr.String(), trusted) {
+			return addr.String()
+		}
+	}
+
+	addr, _ := parseHop(hops[0])
+
+	return addr.String()
+}
+
+// trustedIP checks if the address is in the trusted ranges, by default the
+// loopback, link-local and private addresses are trusted.
+func trustedIP(ip string, trusted []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+
+	if len(trusted) == 0 {
+		return addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsPrivate()
+	}
+
+	for _, p := range trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseHop parses the address of a hop, the port and IPv6 brackets are
+// removed.
+func parseHop(hop string) (netip.Addr, bool) {
+	hop = strings.TrimSpace(hop)
+
+	if addrPort, err := netip.ParseAddrPort(hop); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(hop, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return addr.Unmap(), true
+}
+
+// xffHops returns the addresses of the X-Forwarded-For headers.
+func xffHops(h http.Header) []string {
+	hops := []string{}
+
+	for _, v := range h.Values(echo.HeaderXForwardedFor) {
+		for _, hop := range strings.Split(v, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+
+	return hops
+}
+
+// forwardedHops returns the "for" parameters of the Forwarded headers, the
+// quotes are removed.
+func forwardedHops(h http.Header) []string {
+	hops := []string{}
+
+	for _, v := range h.Values(headerForwarded) {
+		for _, element := range strings.Split(v, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(key, forwardedFor) {
+					hops = append(hops, strings.Trim(value, `"`))
+				}
+			}
+		}
+	}
+
+	return hops
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func ipRequest(remoteAddr string, headers map[string]string) *http.Request {
+	req := httptest.NewRequest(echo.GET, "/", nil)
+	req.RemoteAddr = remoteAddr
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	return req
+}
+
+func TestExtractIPFromForwardedHeader(t *testing.T) {
+	proxies := netip.MustParsePrefix("203.0.113.0/24")
+
+	tests := []struct {
+		name    string
+		req     *http.Request
+		trusted []netip.Prefix
+		want    string
+	}{
+		{"no header", ipRequest("10.0.0.1:1234", nil), nil, "10.0.0.1"},
+		{"untrusted peer", ipRequest("198.51.100.1:1234", map[string]string{
+			headerForwarded: "for=192.0.2.60",
+		}), nil, "198.51.100.1"},
+		{"private proxies", ipRequest("10.0.0.1:1234", map[string]string{
+			headerForwarded: `for=192.0.2.60;proto=http, for="10.0.0.2:8080"`,
+		}), nil, "192.0.2.60"},
+		{"ipv6", ipRequest("10.0.0.1:1234", map[string]string{
+			headerForwarded: `For="[2001:db8:cafe::17]:4711"`,
+		}), nil, "2001:db8:cafe::17"},
+		{"rightmost untrusted", ipRequest("203.0.113.10:1234", map[string]string{
+			headerForwarded: "for=192.0.2.1, for=192.0.2.60, for=203.0.113.20",
+		}), []netip.Prefix{proxies}, "192.0.2.60"},
+		{"unknown hop", ipRequest("10.0.0.1:1234", map[string]string{
+			headerForwarded: "for=unknown",
+		}), nil, "10.0.0.1"},
+		{"all trusted", ipRequest("10.0.0.1:1234", map[string]string{
+			headerForwarded: "for=10.0.0.3, for=10.0.0.2",
+		}), nil, "10.0.0.3"},
+	}
+
+	for _, tt := range tests {
+		if got := ExtractIPFromForwardedHeader(tt.trusted...)(tt.req); got != tt.want {
+			t.Errorf("invalid %s: expect '%s', got '%s'", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestExtractIPFromXFFHeader(t *testing.T) {
+	req := ipRequest("10.0.0.1:1234", map[string]string{
+		echo.HeaderXForwardedFor: "192.0.2.1, 198.51.100.7, 10.0.0.2",
+	})
+
+	if got := ExtractIPFromXFFHeader()(req); got != "198.51.100.7" {
+		t.Errorf("invalid ip: expect '198.51.100.7', got '%s'", got)
+	}
+}
+
+func TestExtractIPFromHeader(t *testing.T) {
+	cloudflare := netip.MustParsePrefix("173.245.48.0/20")
+	headers := map[string]string{"CF-Connecting-IP": "192.0.2.60"}
+
+	tests := []struct {
+		name    string
+		req     *http.Request
+		trusted []netip.Prefix
+		want    string
+	}{
+		{"trusted peer", ipRequest("173.245.48.1:1234", headers), []netip.Prefix{cloudflare}, "192.0.2.60"},
+		{"untrusted peer", ipRequest("198.51.100.1:1234", headers), []netip.Prefix{cloudflare}, "198.51.100.1"},
+		{"default trusted peer", ipRequest("127.0.0.1:1234", headers), nil, "192.0.2.60"},
+		{"invalid header", ipRequest("127.0.0.1:1234", map[string]string{
+			"CF-Connecting-IP": "invalid",
+		}), nil, "127.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		if got := ExtractIPFromHeader("CF-Connecting-IP", tt.trusted...)(tt.req); got != tt.want {
+			t.Errorf("invalid %s: expect '%s', got '%s'", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestMapFieldsWithIPTags(t *testing.T) {
+	req := ipRequest("10.0.0.1:1234", map[string]string{
+		headerForwarded: "for=192.0.2.60, for=10.0.0.2",
+	})
+
+	ec := echo.New().NewContext(req, httptest.NewRecorder())
+	opts := logOptions{
+		fieldMap: map[string]string{
+			"remote_ip":       logRemoteIP,
+			"peer_ip":         logPeerIP,
+			"forwarded_chain": logFwdChain,
+		},
+		ipExtractor: ExtractIPFromForwardedHeader(),
+	}
+
+	entry, _ := mapFields(ec, testHandler, opts)
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"remote_ip", entry.fields["remote_ip"], "192.0.2.60"},
+		{"peer_ip", entry.fields["peer_ip"], "10.0.0.1"},
+		{"forwarded_chain", entry.fields["forwarded_chain"], []string{"192.0.2.60", "10.0.0.2"}},
+	}
+
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("invalid %s: expect '%v', got '%v'", tt.name, tt.want, tt.got)
+		}
+	}
+}
+
+func TestForwardedChainFallback(t *testing.T) {
+	h := http.Header{}
+	h.Add(echo.HeaderXForwardedFor, "192.0.2.1, 10.0.0.2")
+	h.Add(echo.HeaderXForwardedFor, "10.0.0.3")
+
+	if got := forwardedChain(h); !reflect.DeepEqual(got, []string{"192.0.2.1", "10.0.0.2", "10.0.0.3"}) {
+		t.Errorf("invalid chain: got '%v'", got)
+	}
+}
@@ -6,6 +6,10 @@
 package middleware
 
 import (
+	"context"
+	"net/http"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -15,26 +19,52 @@ import (
 
 // Log middlewares constants.
 const (
-	logID           = "@id"
-	logRemoteIP     = "@remote_ip"
-	logURI          = "@uri"
-	logHost         = "@host"
-	logMethod       = "@method"
-	logPath         = "@path"
-	logRoute        = "@route"
-	logProtocol     = "@protocol"
-	logReferer      = "@referer"
-	logUserAgent    = "@user_agent"
-	logStatus       = "@status"
-	logError        = "@error"
-	logLatency      = "@latency"
-	logLatencyHuman = "@latency_human"
-	logBytesIn      = "@bytes_in"
-	logBytesOut     = "@bytes_out"
-	logHeaderPrefix = "@header:"
-	logQueryPrefix  = "@query:"
-	logFormPrefix   = "@form:"
-	logCookiePrefix = "@cookie:"
+	logID              = "@id"
+	logRemoteIP        = "@remote_ip"
+	logPeerIP          = "@peer_ip"
+	logFwdChain        = "@forwarded_chain"
+	logURI             = "@uri"
+	logHost            = "@host"
+	logMethod          = "@method"
+	logPath            = "@path"
+	logRoute           = "@route"
+	logProtocol        = "@protocol"
+	logReferer         = "@referer"
+	logUserAgent       = "@user_agent"
+	logUABrowser       = "@ua_browser"
+	logUABrowserVer    = "@ua_browser_version"
+	logUAOS            = "@ua_os"
+	logUADeviceType    = "@ua_device_type"
+	logUAIsBot         = "@ua_is_bot"
+	logStatus          = "@status"
+	logError           = "@error"
+	logErrorCode       = "@error_code"
+	logErrorMessage    = "@error_message"
+	logErrorInternal   = "@error_internal"
+	logErrorType       = "@error_type"
+	logErrorChain      = "@error_chain"
+	logErrorStack      = "@error_stack"
+	logLatency         = "@latency"
+	logLatencyHuman    = "@latency_human"
+	logBytesIn         = "@bytes_in"
+	logBytesOut        = "@bytes_out"
+	logBodyIn          = "@body_in"
+	logBodyOut         = "@body_out"
+	logTTFB            = "@ttfb"
+	logWriteDur        = "@write_duration"
+	logFlushCount      = "@flush_count"
+	logStartTime       = "@start_time"
+	logEndTime         = "@end_time"
+	logHeaderPrefix    = "@header:"
+	logQueryPrefix     = "@query:"
+	logFormPrefix      = "@form:"
+	logCookiePrefix    = "@cookie:"
+	logParamPrefix     = "@param:"
+	logCtxPrefix       = "@ctx:"
+	logResHeaderPrefix = "@res_header:"
+	logHeadersPrefix   = "@header[]:"
+	logQueriesPrefix   = "@query[]:"
+	logFormsPrefix     = "@form[]:"
 )
 
 var defaultFields = map[string]string{
@@ -47,24 +77,335 @@ var defaultFields = map[string]string{
 	"error":     logError,
 }
 
+// RequestContextFieldMap is a ready to use ContextFieldMap, adding the
+// request id, route and remote ip to request-scoped loggers. It is not set
+// by default.
+var RequestContextFieldMap = map[string]string{
+	"id":        logID,
+	"route":     logRoute,
+	"remote_ip": logRemoteIP,
+}
+
 // string to int base conversion.
 const base = 10
 
-// mapFields maps fields based on tag name.
-func mapFields(ec echo.Context, h echo.HandlerFunc, fm map[string]string) (map[string]interface{}, error) {
-	logFields := map[string]interface{}{}
+// Messages of the request log entries.
+const (
+	logMessage      = "handle request"
+	logStartMessage = "request started"
+)
+
+// Level defines the severity of the log entry emitted by the log middlewares.
+type Level int8
+
+// Log levels supported by every log middleware.
+const (
+	LevelDebug Level = iota - 1
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// LatencyUnit defines the unit of the @latency tag when typed values are
+// enabled.
+type LatencyUnit int
+
+// Latency units supported by typed values.
+const (
+	// LatencyNanoseconds logs the latency as int64 nanoseconds.
+	LatencyNanoseconds LatencyUnit = iota
+
+	// LatencyMicroseconds logs the latency as int64 microseconds.
+	LatencyMicroseconds
+
+	// LatencyMilliseconds logs the latency as int64 milliseconds.
+	LatencyMilliseconds
+
+	// LatencySeconds logs the latency as float64 seconds.
+	LatencySeconds
+
+	// LatencyDuration logs the latency as time.Duration, using the native
+	// duration encoding of each logger.
+	LatencyDuration
+)
+
+// Time formats of @start_time and @end_time tags, besides the time layouts
+// (e.g. time.RFC3339Nano or "2006-01-02 15:04:05").
+const (
+	// TimeFormatUnix logs the time as int64 Unix seconds.
+	TimeFormatUnix = "unix"
+
+	// TimeFormatUnixMilli logs the time as int64 Unix milliseconds.
+	TimeFormatUnixMilli = "unix_milli"
+
+	// TimeFormatUnixMicro logs the time as int64 Unix microseconds.
+	TimeFormatUnixMicro = "unix_micro"
+
+	// TimeFormatUnixNano logs the time as int64 Unix nanoseconds.
+	TimeFormatUnixNano = "unix_nano"
+)
+
+// Fields defines the log fields, mapped from the field map tags.
+type Fields map[string]interface{}
+
+// FieldProcessor defines a function to transform, add or remove log fields
+// before the entry is emitted.
+type FieldProcessor func(ec echo.Context, fields Fields) Fields
+
+// LevelFunc defines a function to resolve the log level of a request, the
+// error returned by the handler is provided.
+type LevelFunc func(ec echo.Context, err error) Level
+
+// StatusLevel resolves the log level based on the response status code:
+// 2xx and 3xx as info, 4xx as warn, 5xx or any error returned by the handler
+// as error.
+func StatusLevel(ec echo.Context, err error) Level {
+	status := ec.Response().Status
+
+	switch {
+	case err != nil, status >= http.StatusInternalServerError:
+		return LevelError
+	case status >= http.StatusBadRequest:
+		return LevelWarn
+	default:
+		return LevelInfo
+	}
+}
+
+// logLevel resolves the log level with the provided function, when it is not
+// defined the info level is returned.
+func logLevel(ec echo.Context, err error, fn LevelFunc) Level {
+	if fn == nil {
+		return LevelInfo
+	}
+
+	return fn(ec, err)
+}
+
+// logOptions defines the options shared by the log middlewares.
+type logOptions struct {
+	fieldMap     map[string]string
+	level        LevelFunc
+	requestBody  BodyConfig
+	responseBody BodyConfig
+	redactor     *Redactor
+	sampler      *Sampler
+	typedValues  bool
+	latencyUnit  LatencyUnit
+	nestedFields bool
+	timeFormat   string
+	timeUTC      bool
+	processors   []FieldProcessor
+	recoverPanic bool
+	slowRequest  *SlowRequest
+	messageTmpl  *messageTemplate
+	messageFunc  MessageFunc
+	errorPolicy  ErrorPolicy
+	ipExtractor  echo.IPExtractor
+
+	// logStart emits the entry of the request start, when it is enabled.
+	logStart func(ec echo.Context, entry logEntry)
+}
+
+// logEntry defines the data emitted by the log middlewares.
+type logEntry struct {
+	fields  map[string]interface{}
+	level   Level
+	message string
+
+	// skip reports the entry was dropped and must not be emitted.
+	skip bool
+}
+
+// hasTag checks if any of the tags is used by the field map.
+func hasTag(fm map[string]string, tags ...string) bool {
+	for _, t := range fm {
+		if slices.Contains(tags, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mapFields calls the handler and maps the log entry fields based on tag
+// name.
+func mapFields(ec echo.Context, h echo.HandlerFunc, opts logOptions) (logEntry, error) {
 	start := time.Now()
 
-	err := h(ec)
-	if err != nil {
-		ec.Error(err)
+	// the start entry is only emitted when the request is kept by the rate,
+	// so every start entry has its completion entry. Otherwise the decision
+	// is taken after the handler, when the request id is set.
+	sampled := true
+	if opts.logStart != nil {
+		sampled = opts.sampler.sampled(ec)
+		if sampled {
+			opts.logStart(ec, startEntry(ec, opts))
+		}
+	}
+
+	var bodyIn string
+	if hasTag(opts.fieldMap, logBodyIn) {
+		bodyIn = captureRequestBody(ec.Request(), bodyConfig(opts.requestBody), opts.redactor)
+	}
+
+	var rw *responseWriter
+	if hasTag(opts.fieldMap, logBodyOut, logTTFB, logWriteDur, logFlushCount) {
+		rw = newResponseWriter(ec.Response())
+
+		if hasTag(opts.fieldMap, logBodyOut) {
+			rw.recordBody(bodyConfig(opts.responseBody))
+		}
+	}
+
+	stack, err := callHandler(ec, h, opts.recoverPanic)
+
+	// the panics are handled, so they are not returned to the previous
+	// middlewares.
+	policy := opts.errorPolicy
+	if stack != nil {
+		policy = ErrorHandleAndSwallow
+	}
+
+	result := policy.handle(ec, err)
+
+	if rw != nil {
+		rw.restore(ec.Response())
 	}
 
 	elapsed := time.Since(start)
-	tags := mapTags(ec, elapsed)
+
+	if opts.logStart == nil {
+		sampled = opts.sampler.sampled(ec)
+	}
+
+	if stack == nil && !opts.sampler.sample(ec, err, elapsed, sampled) {
+		return logEntry{skip: true}, result
+	}
+
+	tags := mapTags(ec, elapsed, opts.ipExtractor)
+	if opts.typedValues {
+		typedTags(ec, tags, elapsed, opts.latencyUnit)
+	}
+
+	tags[logBodyIn] = bodyIn
+	tags[logStartTime] = timeValue(start, opts)
+	tags[logEndTime] = timeValue(start.Add(elapsed), opts)
+	responseTags(ec, tags, rw, opts)
+
+	redactTags(ec, tags, opts.redactor)
 
 	if err != nil {
-		tags[logError] = err
+		errorTags(err, tags, opts)
+	}
+
+	entry := logEntry{
+		fields:  tagFields(ec, opts.fieldMap, tags, opts.redactor),
+		level:   logLevel(ec, err, opts.level),
+		message: logMessage,
+	}
+
+	if threshold, ok := opts.slowRequest.exceeded(ec, elapsed); ok {
+		entry.fields[slowThresholdField] = durationValue(threshold, opts)
+		entry.level = max(entry.level, opts.slowRequest.level())
+		entry.message = slowMessage
+	}
+
+	if stack != nil {
+		entry.fields[recoverStackField] = string(stack)
+		entry.level = LevelError
+		entry.message = recoverMessage
+	}
+
+	entry.fields = processFields(ec, entry.fields, opts.processors)
+	entry.message = entryMessage(ec, entry.message, tags, entry.fields, opts)
+
+	if opts.nestedFields {
+		entry.fields = nestFields(entry.fields)
+	}
+
+	return entry, result
+}
+
+// requestFields maps the field map tags using the request data, it is used
+// before calling the handler.
+func requestFields(ec echo.Context, fm map[string]string, opts logOptions) map[string]interface{} {
+	tags := mapTags(ec, 0, opts.ipExtractor)
+	tags[logStartTime] = timeValue(time.Now(), opts)
+	redactTags(ec, tags, opts.redactor)
+
+	fields := tagFields(ec, fm, tags, opts.redactor)
+	if opts.nestedFields {
+		fields = nestFields(fields)
+	}
+
+	return fields
+}
+
+// startEntry maps the log entry emitted before calling the handler, the tags
+// resolved by the handler or consuming the request body are not mapped.
+func startEntry(ec echo.Context, opts logOptions) logEntry {
+	fm := map[string]string{}
+
+	for k, tag := range opts.fieldMap {
+		if !handlerTag(tag) {
+			fm[k] = tag
+		}
+	}
+
+	// the processors are applied on the flat fields.
+	flat := opts
+	flat.nestedFields = false
+
+	fields := processFields(ec, requestFields(ec, fm, flat), opts.processors)
+	if opts.nestedFields {
+		fields = nestFields(fields)
+	}
+
+	return logEntry{
+		fields:  fields,
+		level:   LevelInfo,
+		message: logStartMessage,
+	}
+}
+
+// handlerTag checks if the tag value is resolved by the handler, or if it
+// consumes the request body.
+func handlerTag(tag string) bool {
+	switch tag {
+	case logStatus, logError, logErrorCode, logErrorMessage, logErrorInternal,
+		logErrorType, logErrorChain, logErrorStack, logLatency, logLatencyHuman,
+		logBytesOut, logBodyIn, logBodyOut, logTTFB, logWriteDur, logFlushCount,
+		logEndTime:
+		return true
+	}
+
+	for _, p := range []string{logFormPrefix, logFormsPrefix, logResHeaderPrefix} {
+		if strings.HasPrefix(tag, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactTags masks the query params of @uri and @referer tags.
+func redactTags(ec echo.Context, tags map[string]interface{}, r *Redactor) {
+	if r == nil {
+		return
+	}
+
+	tags[logURI] = r.RedactURI(ec.Request().RequestURI)
+	tags[logReferer] = r.RedactURI(ec.Request().Referer())
+}
+
+// tagFields maps the field map tags into log fields.
+func tagFields(ec echo.Context, fm map[string]string, tags map[string]interface{}, r *Redactor) map[string]interface{} {
+	logFields := map[string]interface{}{}
+
+	// the User-Agent is only parsed when it is requested.
+	if hasTag(fm, uaTags...) {
+		userAgentTags(ec.Request().UserAgent(), tags)
 	}
 
 	for k, tag := range fm {
@@ -80,41 +421,166 @@ func mapFields(ec echo.Context, h echo.HandlerFunc, fm map[string]string) (map[s
 		switch {
 		case strings.HasPrefix(tag, logHeaderPrefix):
 			key := tag[len(logHeaderPrefix):]
-			logFields[k] = ec.Request().Header.Get(key)
+			logFields[k] = r.Redact(RedactHeader, key, ec.Request().Header.Get(key))
 		case strings.HasPrefix(tag, logQueryPrefix):
 			key := tag[len(logQueryPrefix):]
-			logFields[k] = ec.QueryParam(key)
+			logFields[k] = r.Redact(RedactQuery, key, ec.QueryParam(key))
 		case strings.HasPrefix(tag, logFormPrefix):
 			key := tag[len(logFormPrefix):]
-			logFields[k] = ec.FormValue(key)
+			logFields[k] = r.Redact(RedactForm, key, ec.FormValue(key))
 		case strings.HasPrefix(tag, logCookiePrefix):
 			key := tag[len(logCookiePrefix):]
 			cookie, err := ec.Cookie(key)
 			if err == nil {
-				logFields[k] = cookie.Value
+				logFields[k] = r.Redact(RedactCookie, key, cookie.Value)
+			}
+		case strings.HasPrefix(tag, logHeadersPrefix):
+			key := tag[len(logHeadersPrefix):]
+			logFields[k] = r.RedactValues(RedactHeader, key, ec.Request().Header.Values(key))
+		case strings.HasPrefix(tag, logQueriesPrefix):
+			key := tag[len(logQueriesPrefix):]
+			logFields[k] = r.RedactValues(RedactQuery, key, ec.QueryParams()[key])
+		case strings.HasPrefix(tag, logFormsPrefix):
+			key := tag[len(logFormsPrefix):]
+			params, _ := ec.FormParams()
+			logFields[k] = r.RedactValues(RedactForm, key, params[key])
+		case strings.HasPrefix(tag, logParamPrefix):
+			logFields[k] = ec.Param(tag[len(logParamPrefix):])
+		case strings.HasPrefix(tag, logCtxPrefix):
+			logFields[k] = ec.Get(tag[len(logCtxPrefix):])
+		case strings.HasPrefix(tag, logResHeaderPrefix):
+			key := tag[len(logResHeaderPrefix):]
+			logFields[k] = r.Redact(RedactHeader, key, ec.Response().Header().Get(key))
+		default:
+			if value, ok := customTagValue(ec, tag); ok {
+				logFields[k] = value
 			}
 		}
 	}
 
-	return logFields, err
+	return logFields
+}
+
+// processFields applies the processors chain on the fields.
+func processFields(ec echo.Context, fields map[string]interface{}, processors []FieldProcessor) map[string]interface{} {
+	for _, p := range processors {
+		fields = p(ec, fields)
+		if fields == nil {
+			fields = map[string]interface{}{}
+		}
+	}
+
+	return fields
+}
+
+// nestFields converts the dotted keys into nested fields, e.g. the key
+// "http.request.method" becomes {"http": {"request": {"method": ...}}}. When
+// a key conflicts with a non nested value, it is kept flat.
+func nestFields(fields map[string]interface{}) map[string]interface{} {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	nested := map[string]interface{}{}
+
+	for _, k := range keys {
+		parts := strings.Split(k, ".")
+		node := nested
+
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := node[part].(map[string]interface{})
+			if !ok {
+				if _, exists := node[part]; exists {
+					node = nil
+					break
+				}
+
+				child = map[string]interface{}{}
+				node[part] = child
+			}
+
+			node = child
+		}
+
+		if node == nil {
+			nested[k] = fields[k]
+			continue
+		}
+
+		node[parts[len(parts)-1]] = fields[k]
+	}
+
+	return nested
+}
+
+// responseTags maps the tags recorded by the response writer, the response
+// body respects the error statuses restriction.
+func responseTags(ec echo.Context, tags map[string]interface{}, rw *responseWriter, opts logOptions) {
+	if rw == nil {
+		return
+	}
+
+	ttfb, writing := rw.durations()
+
+	tags[logTTFB] = durationValue(ttfb, opts)
+	tags[logWriteDur] = durationValue(writing, opts)
+	tags[logFlushCount] = rw.flushes
+	tags[logBodyOut] = ""
+
+	if !opts.responseBody.ErrorsOnly || ec.Response().Status >= http.StatusBadRequest {
+		tags[logBodyOut] = rw.bodyValue()
+	}
+}
+
+// timeValue formats the time with the time format, by default as
+// time.RFC3339Nano in local time.
+func timeValue(t time.Time, opts logOptions) interface{} {
+	if opts.timeUTC {
+		t = t.UTC()
+	}
+
+	switch opts.timeFormat {
+	case "":
+		return t.Format(time.RFC3339Nano)
+	case TimeFormatUnix:
+		return t.Unix()
+	case TimeFormatUnixMilli:
+		return t.UnixMilli()
+	case TimeFormatUnixMicro:
+		return t.UnixMicro()
+	case TimeFormatUnixNano:
+		return t.UnixNano()
+	default:
+		return t.Format(opts.timeFormat)
+	}
+}
+
+// durationValue converts the duration into nanoseconds string, or into the
+// latency unit when typed values are enabled.
+func durationValue(d time.Duration, opts logOptions) interface{} {
+	if opts.typedValues {
+		return latencyValue(d, opts.latencyUnit)
+	}
+
+	return strconv.FormatInt(int64(d), base)
 }
 
 // mapTags maps the log tags with its related data. Populate previously the
 // key/value avoids the cyclomatic complexity of the log middlewares to
 // identify each tag and value.
-func mapTags(ec echo.Context, latency time.Duration) map[string]interface{} {
+func mapTags(ec echo.Context, latency time.Duration, extract echo.IPExtractor) map[string]interface{} {
 	tags := map[string]interface{}{}
 
 	req := ec.Request()
 	res := ec.Response()
 
-	id := req.Header.Get(echo.HeaderXRequestID)
-	if id == "" {
-		id = res.Header().Get(echo.HeaderXRequestID)
-	}
-
-	tags[logID] = id
-	tags[logRemoteIP] = ec.RealIP()
+	tags[logID] = requestID(ec)
+	tags[logRemoteIP] = remoteIP(ec, extract)
+	tags[logPeerIP] = peerIP(req)
+	tags[logFwdChain] = forwardedChain(req.Header)
 	tags[logURI] = req.RequestURI
 	tags[logHost] = req.Host
 	tags[logMethod] = req.Method
@@ -143,3 +609,47 @@ func mapTags(ec echo.Context, latency time.Duration) map[string]interface{} {
 
 	return tags
 }
+
+// typedTags replaces the stringified numeric tags with typed values.
+func typedTags(ec echo.Context, tags map[string]interface{}, latency time.Duration, unit LatencyUnit) {
+	bytesIn, _ := strconv.ParseInt(tags[logBytesIn].(string), base, 64)
+
+	tags[logLatency] = latencyValue(latency, unit)
+	tags[logBytesIn] = bytesIn
+	tags[logBytesOut] = ec.Response().Size
+}
+
+// latencyValue converts the latency into the unit value.
+func latencyValue(latency time.Duration, unit LatencyUnit) interface{} {
+	switch unit {
+	case LatencyMicroseconds:
+		return latency.Microseconds()
+	case LatencyMilliseconds:
+		return latency.Milliseconds()
+	case LatencySeconds:
+		return latency.Seconds()
+	case LatencyDuration:
+		return latency
+	default:
+		return latency.Nanoseconds()
+	}
+}
+
+// requestID returns the request id from request header, otherwise from the
+// response header.
+func requestID(ec echo.Context) string {
+	id := ec.Request().Header.Get(echo.HeaderXRequestID)
+	if id == "" {
+		id = ec.Response().Header().Get(echo.HeaderXRequestID)
+	}
+
+	return id
+}
+
+// withContextValue stores the value into the request context.
+func withContextValue(ec echo.Context, key, value interface{}) {
+	req := ec.Request()
+	ctx := context.WithValue(req.Context(), key, value)
+
+	ec.SetRequest(req.WithContext(ctx))
+}
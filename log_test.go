@@ -12,6 +12,7 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
@@ -38,6 +39,10 @@ var testFields = map[string]string{
 	"latency_human": logLatencyHuman,
 	"bytes_in":      logBytesIn,
 	"bytes_out":     logBytesOut,
+	"body_in":       logBodyIn,
+	"body_out":      logBodyOut,
+	"param_id":      logParamPrefix + "id",
+	"res_id":        logResHeaderPrefix + echo.HeaderXRequestID,
 	"user":          logHeaderPrefix + "user",
 }
 
@@ -100,7 +105,7 @@ func postCtx(t *testing.T) echo.Context {
 
 	ec := e.NewContext(req, rec)
 
-	e.Router().Find(echo.GET, u.RequestURI(), ec)
+	e.Router().Find(echo.GET, u.Path, ec)
 
 	return ec
 }
@@ -108,3 +113,422 @@ func postCtx(t *testing.T) echo.Context {
 func panicCtx(t *testing.T) echo.Context {
 	return testCtx(t, "/some?panic=1")
 }
+
+func TestStatusLevel(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		err    error
+		want   Level
+	}{
+		{"ok", http.StatusOK, nil, LevelInfo},
+		{"redirect", http.StatusFound, nil, LevelInfo},
+		{"client_error", http.StatusNotFound, nil, LevelWarn},
+		{"server_error", http.StatusBadGateway, nil, LevelError},
+		{"handler_error", http.StatusOK, errors.New("error"), LevelError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ec := reqCtx(t)
+			ec.Response().Status = tt.status
+
+			if got := StatusLevel(ec, tt.err); got != tt.want {
+				t.Errorf("expect level '%d', got '%d'", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMapFieldsResponseBodyErrorsOnly(t *testing.T) {
+	opts := logOptions{
+		fieldMap:     map[string]string{"body_out": logBodyOut},
+		responseBody: BodyConfig{ErrorsOnly: true},
+	}
+
+	entry, _ := mapFields(reqCtx(t), testHandler, opts)
+	if entry.fields["body_out"] != "" {
+		t.Errorf("expect empty body for success status, got '%v'", entry.fields["body_out"])
+	}
+
+	entry, _ = mapFields(errCtx(t), testHandler, opts)
+	if entry.fields["body_out"] == "" {
+		t.Errorf("expect body for error status")
+	}
+}
+
+func TestMapFieldsWithRedactor(t *testing.T) {
+	opts := logOptions{
+		fieldMap: testFields,
+		redactor: NewRedactor(
+			RedactRule{Keys: []string{"user", "session", "username"}},
+			RedactRule{Keys: []string{"name"}, Sources: RedactQuery},
+		),
+	}
+
+	entry, _ := mapFields(postCtx(t), testHandler, opts)
+	fields := entry.fields
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"user", redactedValue},
+		{"session", redactedValue},
+		{"username", redactedValue},
+		{"filter_name", redactedValue},
+		{"uri", "http://some/foo/456?name=%5BREDACTED%5D"},
+		{"store", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if fields[tt.name] != tt.want {
+				t.Errorf("expect '%s' as '%s', got '%v'", tt.name, tt.want, fields[tt.name])
+			}
+		})
+	}
+}
+
+func TestLatencyValue(t *testing.T) {
+	latency := 1500 * time.Millisecond
+
+	tests := []struct {
+		name string
+		unit LatencyUnit
+		want interface{}
+	}{
+		{"nanoseconds", LatencyNanoseconds, int64(1500000000)},
+		{"microseconds", LatencyMicroseconds, int64(1500000)},
+		{"milliseconds", LatencyMilliseconds, int64(1500)},
+		{"seconds", LatencySeconds, 1.5},
+		{"duration", LatencyDuration, latency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := latencyValue(latency, tt.unit); got != tt.want {
+				t.Errorf("expect latency '%v' (%T), got '%v' (%T)", tt.want, tt.want, got, got)
+			}
+		})
+	}
+}
+
+func TestMapFieldsWithTypedValues(t *testing.T) {
+	opts := logOptions{
+		fieldMap:    testFields,
+		typedValues: true,
+		latencyUnit: LatencyMilliseconds,
+	}
+
+	entry, _ := mapFields(postCtx(t), testHandler, opts)
+
+	if _, ok := entry.fields["latency"].(int64); !ok {
+		t.Errorf("expect latency as int64, got '%T'", entry.fields["latency"])
+	}
+
+	if entry.fields["bytes_in"] != int64(0) {
+		t.Errorf("expect bytes_in as int64, got '%T'", entry.fields["bytes_in"])
+	}
+
+	if entry.fields["bytes_out"] != int64(4) {
+		t.Errorf("expect bytes_out as int64, got '%T'", entry.fields["bytes_out"])
+	}
+
+	if entry.fields["status"] != http.StatusOK {
+		t.Errorf("expect status as int, got '%T'", entry.fields["status"])
+	}
+}
+
+func TestNestFields(t *testing.T) {
+	fields := nestFields(map[string]interface{}{
+		"http.request.method":      "GET",
+		"http.response.status":     200,
+		"url.path":                 "/some",
+		"url":                      "conflict",
+		"event.duration":           1,
+		"event.duration.precision": "ns",
+	})
+
+	httpFields, ok := fields["http"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expect nested http field, got '%T'", fields["http"])
+	}
+
+	request, _ := httpFields["request"].(map[string]interface{})
+	if request["method"] != "GET" {
+		t.Errorf("expect nested method, got '%v'", request["method"])
+	}
+
+	response, _ := httpFields["response"].(map[string]interface{})
+	if response["status"] != 200 {
+		t.Errorf("expect nested status, got '%v'", response["status"])
+	}
+
+	if fields["url"] != "conflict" || fields["url.path"] != "/some" {
+		t.Errorf("expect conflicting keys kept flat, got '%v'", fields)
+	}
+
+	if fields["event.duration.precision"] != "ns" {
+		t.Errorf("expect conflicting nested key kept flat, got '%v'", fields)
+	}
+}
+
+func TestMapFieldsWithContextValue(t *testing.T) {
+	opts := logOptions{
+		fieldMap: map[string]string{
+			"principal": logCtxPrefix + "principal",
+			"missing":   logCtxPrefix + "missing",
+			"location":  logResHeaderPrefix + echo.HeaderLocation,
+		},
+	}
+
+	ec := reqCtx(t)
+	ec.Set("principal", "john")
+
+	entry, _ := mapFields(ec, func(ec echo.Context) error {
+		return ec.Redirect(http.StatusFound, "/login")
+	}, opts)
+
+	if entry.fields["principal"] != "john" {
+		t.Errorf("expect context value 'john', got '%v'", entry.fields["principal"])
+	}
+
+	if entry.fields["missing"] != nil {
+		t.Errorf("expect nil context value, got '%v'", entry.fields["missing"])
+	}
+
+	if entry.fields["location"] != "/login" {
+		t.Errorf("expect response header '/login', got '%v'", entry.fields["location"])
+	}
+}
+
+func multiValueCtx(t *testing.T) echo.Context {
+	t.Helper()
+
+	form := url.Values{}
+	form.Add("tag", "a")
+	form.Add("tag", "b")
+
+	req := httptest.NewRequest(echo.POST, "/some?id=1&id=2", strings.NewReader(form.Encode()))
+	req.Header.Add(echo.HeaderContentType, echo.MIMEApplicationForm)
+	req.Header.Add(echo.HeaderXForwardedFor, "10.0.0.1")
+	req.Header.Add(echo.HeaderXForwardedFor, "10.0.0.2")
+
+	return echo.New().NewContext(req, httptest.NewRecorder())
+}
+
+func TestMapFieldsWithMultiValues(t *testing.T) {
+	opts := logOptions{
+		fieldMap: map[string]string{
+			"forwarded": logHeadersPrefix + echo.HeaderXForwardedFor,
+			"ids":       logQueriesPrefix + "id",
+			"tags":      logFormsPrefix + "tag",
+			"missing":   logQueriesPrefix + "missing",
+		},
+		redactor: NewRedactor(RedactRule{Keys: []string{"tag"}, Sources: RedactForm}),
+	}
+
+	entry, _ := mapFields(multiValueCtx(t), testHandler, opts)
+
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"forwarded", []string{"10.0.0.1", "10.0.0.2"}},
+		{"ids", []string{"1", "2"}},
+		{"tags", []string{redactedValue, redactedValue}},
+		{"missing", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := entry.fields[tt.name].([]string)
+			if !ok || strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expect '%s' as '%v', got '%v'", tt.name, tt.want, entry.fields[tt.name])
+			}
+		})
+	}
+}
+
+func TestMapFieldsWithStreamingTags(t *testing.T) {
+	opts := logOptions{
+		fieldMap: map[string]string{
+			"ttfb":           logTTFB,
+			"write_duration": logWriteDur,
+			"flush_count":    logFlushCount,
+		},
+		typedValues: true,
+		latencyUnit: LatencyDuration,
+	}
+
+	entry, _ := mapFields(reqCtx(t), func(ec echo.Context) error {
+		res := ec.Response()
+		res.Header().Set(echo.HeaderContentType, mimeEventStream)
+		res.WriteHeader(http.StatusOK)
+
+		for i := 0; i < 3; i++ {
+			_, _ = res.Write([]byte("data: ping\n\n"))
+			res.Flush()
+		}
+
+		return nil
+	}, opts)
+
+	if _, ok := entry.fields["ttfb"].(time.Duration); !ok {
+		t.Errorf("expect ttfb as duration, got '%T'", entry.fields["ttfb"])
+	}
+
+	if _, ok := entry.fields["write_duration"].(time.Duration); !ok {
+		t.Errorf("expect write_duration as duration, got '%T'", entry.fields["write_duration"])
+	}
+
+	if entry.fields["flush_count"] != 3 {
+		t.Errorf("expect 3 flushes, got '%v'", entry.fields["flush_count"])
+	}
+}
+
+func TestTimeValue(t *testing.T) {
+	ts := time.Date(2024, time.May, 10, 13, 14, 15, 123456789, time.FixedZone("BRT", -3*60*60))
+
+	tests := []struct {
+		name   string
+		format string
+		utc    bool
+		want   interface{}
+	}{
+		{"default", "", false, "2024-05-10T13:14:15.123456789-03:00"},
+		{"utc", "", true, "2024-05-10T16:14:15.123456789Z"},
+		{"layout", "2006-01-02 15:04:05", true, "2024-05-10 16:14:15"},
+		{"unix", TimeFormatUnix, false, int64(1715357655)},
+		{"unix_milli", TimeFormatUnixMilli, false, int64(1715357655123)},
+		{"unix_micro", TimeFormatUnixMicro, false, int64(1715357655123456)},
+		{"unix_nano", TimeFormatUnixNano, false, int64(1715357655123456789)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := timeValue(ts, logOptions{timeFormat: tt.format, timeUTC: tt.utc})
+			if got != tt.want {
+				t.Errorf("expect time '%v', got '%v'", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMapFieldsWithTimeTags(t *testing.T) {
+	opts := logOptions{
+		fieldMap: map[string]string{
+			"start_time": logStartTime,
+			"end_time":   logEndTime,
+		},
+		timeFormat: TimeFormatUnixNano,
+	}
+
+	before := time.Now().UnixNano()
+	entry, _ := mapFields(reqCtx(t), testHandler, opts)
+
+	start, _ := entry.fields["start_time"].(int64)
+	end, _ := entry.fields["end_time"].(int64)
+
+	if start < before || end < start {
+		t.Errorf("invalid request times: start '%d', end '%d'", start, end)
+	}
+}
+
+func TestMapFieldsWithProcessors(t *testing.T) {
+	statusClass := func(_ echo.Context, fields Fields) Fields {
+		fields["status_class"] = "2xx"
+		return fields
+	}
+
+	dropMethod := func(_ echo.Context, fields Fields) Fields {
+		delete(fields, "method")
+		return fields
+	}
+
+	opts := logOptions{
+		fieldMap: map[string]string{
+			"method": logMethod,
+			"path":   logPath,
+		},
+		processors: []FieldProcessor{statusClass, dropMethod},
+	}
+
+	entry, _ := mapFields(postCtx(t), testHandler, opts)
+
+	if entry.fields["status_class"] != "2xx" {
+		t.Errorf("expect status_class field, got '%v'", entry.fields)
+	}
+
+	if _, ok := entry.fields["method"]; ok {
+		t.Errorf("expect method field to be removed, got '%v'", entry.fields)
+	}
+
+	if entry.fields["path"] != "/foo/456" {
+		t.Errorf("expect path field, got '%v'", entry.fields)
+	}
+}
+
+func TestProcessFieldsReturningNil(t *testing.T) {
+	fields := processFields(nil, map[string]interface{}{"path": "/"}, []FieldProcessor{
+		func(echo.Context, Fields) Fields { return nil },
+	})
+
+	if fields == nil || len(fields) != 0 {
+		t.Errorf("expect empty fields, got '%v'", fields)
+	}
+}
+
+func TestStartEntry(t *testing.T) {
+	ec := postCtx(t)
+	entry := startEntry(ec, logOptions{fieldMap: testFields})
+
+	if entry.level != LevelInfo || entry.message != logStartMessage {
+		t.Errorf("invalid entry: level '%v', message '%s'", entry.level, entry.message)
+	}
+
+	for _, k := range []string{"method", "uri", "remote_ip", "id", "store"} {
+		if _, ok := entry.fields[k]; !ok {
+			t.Errorf("expect %s field, got '%v'", k, entry.fields)
+		}
+	}
+
+	for _, k := range []string{"status", "latency", "bytes_out", "error", "username"} {
+		if _, ok := entry.fields[k]; ok {
+			t.Errorf("expect no %s field, got '%v'", k, entry.fields[k])
+		}
+	}
+
+	if ec.Request().Form != nil {
+		t.Errorf("expect request form not to be parsed")
+	}
+}
+
+func TestMapFieldsWithRedactedBody(t *testing.T) {
+	form := url.Values{}
+	form.Add("user", "a")
+	form.Add("password", "hunter2")
+
+	req := httptest.NewRequest(echo.POST, "/", strings.NewReader(form.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+
+	ec := echo.New().NewContext(req, httptest.NewRecorder())
+	opts := logOptions{
+		fieldMap: map[string]string{
+			"b": logBodyIn,
+			"p": logFormPrefix + "password",
+		},
+		redactor: NewRedactor(RedactRule{Keys: []string{"password"}}),
+	}
+
+	entry, _ := mapFields(ec, testHandler, opts)
+
+	if body, _ := entry.fields["b"].(string); strings.Contains(body, "hunter2") {
+		t.Errorf("expect password to be redacted from body, got '%s'", body)
+	}
+
+	if entry.fields["p"] != redactedValue {
+		t.Errorf("expect password to be redacted, got '%v'", entry.fields["p"])
+	}
+}
@@ -6,6 +6,8 @@
 package middleware
 
 import (
+	"context"
+
 	"github.com/labstack/echo/v4"
 	mw "github.com/labstack/echo/v4/middleware"
 	"github.com/sirupsen/logrus"
@@ -18,7 +20,9 @@ type LogrusConfig struct {
 	// Tags to constructed the logger fields.
 	//
 	// - @id (Request ID)
-	// - @remote_ip
+	// - @remote_ip (Client IP, see IPExtractor)
+	// - @peer_ip (Connection peer IP)
+	// - @forwarded_chain (Proxy hops of Forwarded or X-Forwarded-For)
 	// - @uri
 	// - @host
 	// - @method
@@ -27,18 +31,131 @@ type LogrusConfig struct {
 	// - @protocol
 	// - @referer
 	// - @user_agent
+	// - @ua_browser (Browser name parsed from the User-Agent)
+	// - @ua_browser_version (Browser major version)
+	// - @ua_os (Operating system name)
+	// - @ua_device_type (desktop, mobile, tablet, bot or Other)
+	// - @ua_is_bot (Crawlers, monitors and http clients)
 	// - @status
 	// - @error
-	// - @latency (In nanoseconds)
+	// - @error_code (HTTP error code)
+	// - @error_message (HTTP error message or error string)
+	// - @error_internal (Internal error of HTTP errors)
+	// - @error_type (Go type of the error)
+	// - @error_chain (Messages of the wrapped errors)
+	// - @error_stack (Stack trace, when the error provides one)
+	// - @latency (In nanoseconds, see LatencyUnit for typed values)
 	// - @latency_human (Human readable)
 	// - @bytes_in (Bytes received)
 	// - @bytes_out (Bytes sent)
+	// - @body_in (Request body, see RequestBody)
+	// - @body_out (Response body, see ResponseBody)
+	// - @ttfb (Time to first byte, in nanoseconds)
+	// - @write_duration (Time spent writing the response, in nanoseconds)
+	// - @flush_count (Number of response flushes)
+	// - @start_time (Request start time, see TimeFormat)
+	// - @end_time (Request end time, see TimeFormat)
 	// - @header:<NAME>
 	// - @query:<NAME>
 	// - @form:<NAME>
 	// - @cookie:<NAME>
+	// - @header[]:<NAME> (All values of the header)
+	// - @query[]:<NAME> (All values of the query param)
+	// - @form[]:<NAME> (All values of the form field)
+	// - @param:<NAME> (Path parameter)
+	// - @ctx:<KEY> (Value stored with echo.Context Set)
+	// - @res_header:<NAME> (Response header)
+	//
+	// Custom tags can be added with `RegisterTag()` and `RegisterTagPrefix()`.
 	FieldMap map[string]string
 
+	// ContextFieldMap set a list of fields with tags added to the logger
+	// stored in the request context, see `LogrusFromContext()`. When it is
+	// empty the Logger is stored as is (e.g. RequestContextFieldMap).
+	ContextFieldMap map[string]string
+
+	// RequestBody defines how the request body is captured by the @body_in
+	// tag.
+	RequestBody BodyConfig
+
+	// ResponseBody defines how the response body is captured by the
+	// @body_out tag.
+	ResponseBody BodyConfig
+
+	// Redactor masks sensitive values of headers, query params, form fields
+	// and cookies, including the query params of @uri and @referer.
+	Redactor *Redactor
+
+	// Sampler defines which requests are logged, by default all requests are
+	// logged.
+	Sampler *Sampler
+
+	// TypedValues logs @latency, @bytes_in and @bytes_out as numbers instead
+	// of strings.
+	TypedValues bool
+
+	// LatencyUnit defines the unit of @latency when TypedValues is enabled.
+	// Default: LatencyNanoseconds.
+	LatencyUnit LatencyUnit
+
+	// NestedFields converts dotted FieldMap keys (e.g. "http.request.method")
+	// into nested objects.
+	NestedFields bool
+
+	// TimeFormat defines the format of @start_time and @end_time, a time
+	// layout or one of the TimeFormatUnix constants.
+	// Default: time.RFC3339Nano.
+	TimeFormat string
+
+	// TimeUTC converts @start_time and @end_time to UTC, otherwise the local
+	// time is used.
+	TimeUTC bool
+
+	// Async emits the log entries in background workers, by default they are
+	// emitted synchronously.
+	Async *AsyncLogger
+
+	// Processors defines a chain of functions applied on the log fields
+	// before the entry is emitted.
+	Processors []FieldProcessor
+
+	// Recover recovers from panics in the handler chain, emitting a single
+	// error entry with the FieldMap fields and the stacktrace. The 500
+	// response is sent by the echo error handler.
+	Recover bool
+
+	// SlowRequest logs the requests exceeding a latency threshold with the
+	// "slow request" message and an elevated level.
+	SlowRequest *SlowRequest
+
+	// LogStart emits a "request started" entry before calling the handler,
+	// with the FieldMap tags known at that point (e.g. @id, @method, @uri).
+	// The response, error, form and body tags are not logged, and the
+	// entry is only emitted when the request is kept by the Sampler rate.
+	LogStart bool
+
+	// Message defines a template of the request entry message, the
+	// placeholders are tag names without "@" (e.g. "{method} {route}
+	// {status} in {latency_human}"). Default: "handle request".
+	Message string
+
+	// MessageFunc builds the request entry message from the log fields, it
+	// has precedence over Message.
+	MessageFunc MessageFunc
+
+	// ErrorPolicy defines how the handler error is handled and propagated.
+	// Default: ErrorHandleAndPropagate.
+	ErrorPolicy ErrorPolicy
+
+	// IPExtractor defines how the client IP of @remote_ip is extracted
+	// (e.g. `echo.ExtractIPDirect()`, `ExtractIPFromForwardedHeader()`), by
+	// default the echo.Context RealIP is used.
+	IPExtractor echo.IPExtractor
+
+	// Level defines a function to resolve the log level of each request,
+	// when it is not defined the requests are logged as info.
+	Level LevelFunc
+
 	// Logger it is a logrus logger
 	Logger logrus.FieldLogger
 
@@ -46,6 +163,9 @@ type LogrusConfig struct {
 	Skipper mw.Skipper
 }
 
+// logrusKey key used to store the request-scoped logger in context.
+var logrusKey = &ctxkey{"logrus-logger"}
+
 // DefaultLogrusConfig is the default Logrus middleware config.
 var DefaultLogrusConfig = LogrusConfig{
 	FieldMap: defaultFields,
@@ -74,14 +194,54 @@ func LogrusWithConfig(cfg LogrusConfig) echo.MiddlewareFunc {
 		cfg.FieldMap = DefaultLogrusConfig.FieldMap
 	}
 
+	opts := logOptions{
+		fieldMap:     cfg.FieldMap,
+		level:        cfg.Level,
+		requestBody:  cfg.RequestBody,
+		responseBody: cfg.ResponseBody,
+		redactor:     cfg.Redactor,
+		sampler:      cfg.Sampler,
+		typedValues:  cfg.TypedValues,
+		latencyUnit:  cfg.LatencyUnit,
+		nestedFields: cfg.NestedFields,
+		timeFormat:   cfg.TimeFormat,
+		timeUTC:      cfg.TimeUTC,
+		processors:   cfg.Processors,
+		recoverPanic: cfg.Recover,
+		slowRequest:  cfg.SlowRequest,
+		messageTmpl:  newMessageTemplate(cfg.Message),
+		messageFunc:  cfg.MessageFunc,
+		errorPolicy:  cfg.ErrorPolicy,
+		ipExtractor:  cfg.IPExtractor,
+	}
+
+	if cfg.LogStart {
+		opts.logStart = func(_ echo.Context, start logEntry) {
+			cfg.Async.emit(func() {
+				logrusEntry(cfg.Logger, start)
+			})
+		}
+	}
+
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ec echo.Context) (err error) {
 			if cfg.Skipper(ec) {
 				return next(ec)
 			}
 
-			logFields, err := mapFields(ec, next, cfg.FieldMap)
-			cfg.Logger.WithFields(logFields).Print("handle request")
+			fields := logrus.Fields{}
+			if len(cfg.ContextFieldMap) > 0 {
+				fields = requestFields(ec, cfg.ContextFieldMap, opts)
+			}
+
+			withContextValue(ec, logrusKey, cfg.Logger.WithFields(fields))
+
+			entry, err := mapFields(ec, next, opts)
+			if !entry.skip {
+				cfg.Async.emit(func() {
+					logrusEntry(cfg.Logger, entry)
+				})
+			}
 
 			return
 		}
@@ -90,11 +250,53 @@ func LogrusWithConfig(cfg LogrusConfig) echo.MiddlewareFunc {
 
 // LogrusRecoverFn returns a Logrus recover log function to print panic errors.
 func LogrusRecoverFn(logger *logrus.Logger) mw.LogErrorFunc {
-	return func(_ echo.Context, err error, stack []byte) error {
-		logger.WithField("stacktrace", string(stack)).
+	return LogrusRecoverFnWithMessage(logger, "")
+}
+
+// LogrusRecoverFnWithMessage returns a Logrus recover log function to print
+// panic errors with the message template appended to "panic recover".
+//
+// The template uses the placeholders of the Message config (e.g. "{method}
+// {uri}"), the response tags are not resolved at this point.
+func LogrusRecoverFnWithMessage(logger *logrus.Logger, tmpl string) mw.LogErrorFunc {
+	message := recoverFnMessage(tmpl)
+
+	return func(ec echo.Context, err error, stack []byte) error {
+		logger.WithField(recoverStackField, string(stack)).
 			WithError(err).
-			Error("panic recover")
+			Error(message(ec))
 
 		return err
 	}
 }
+
+// LogrusFromContext returns the request-scoped logger stored by Logrus
+// middleware, otherwise returns an entry of the default config logger (e.g.
+// outside the middleware chain).
+func LogrusFromContext(ctx context.Context) *logrus.Entry {
+	if logger, ok := ctx.Value(logrusKey).(*logrus.Entry); ok {
+		return logger
+	}
+
+	return DefaultLogrusConfig.Logger.WithFields(logrus.Fields{})
+}
+
+// logrusEntry emits the log entry using logrus logger.
+func logrusEntry(logger logrus.FieldLogger, entry logEntry) {
+	logger.WithFields(entry.fields).
+		Log(logrusLevel(entry.level), entry.message)
+}
+
+// logrusLevel converts the log level into logrus level.
+func logrusLevel(lvl Level) logrus.Level {
+	switch lvl {
+	case LevelDebug:
+		return logrus.DebugLevel
+	case LevelWarn:
+		return logrus.WarnLevel
+	case LevelError:
+		return logrus.ErrorLevel
+	default:
+		return logrus.InfoLevel
+	}
+}
@@ -7,6 +7,8 @@ package middleware
 
 import (
 	"bytes"
+	"context"
+	"io"
 	"strings"
 	"testing"
 
@@ -134,3 +136,189 @@ func TestLogrusRecoverFn(t *testing.T) {
 		t.Errorf("invalid log: error not found")
 	}
 }
+
+func TestLogrusWithLevel(t *testing.T) {
+	ec := errCtx(t)
+	b := new(bytes.Buffer)
+
+	logger := logrus.New()
+	logger.Out = b
+
+	config := LogrusConfig{
+		Logger: logger,
+		Level:  StatusLevel,
+	}
+
+	_ = LogrusWithConfig(config)(testHandler)(ec)
+
+	if !strings.Contains(b.String(), "level=error") {
+		t.Errorf("invalid log: expect error level")
+	}
+}
+
+func TestLogrusWithNestedFields(t *testing.T) {
+	ec := postCtx(t)
+	b := new(bytes.Buffer)
+
+	logger := logrus.New()
+	logger.Out = b
+	logger.Formatter = &logrus.JSONFormatter{}
+
+	config := LogrusConfig{
+		Logger: logger,
+		FieldMap: map[string]string{
+			"http.request.method": logMethod,
+		},
+		NestedFields: true,
+	}
+
+	_ = LogrusWithConfig(config)(testHandler)(ec)
+
+	if !strings.Contains(b.String(), `"http":{"request":{"method":"POST"}}`) {
+		t.Errorf("invalid log: expect nested fields, got '%s'", b.String())
+	}
+}
+
+func TestLogrusWithContextLogger(t *testing.T) {
+	ec := postCtx(t)
+	b := new(bytes.Buffer)
+
+	logger := logrus.New()
+	logger.Out = b
+
+	config := LogrusConfig{
+		Logger:          logger,
+		ContextFieldMap: RequestContextFieldMap,
+	}
+
+	_ = LogrusWithConfig(config)(func(ec echo.Context) error {
+		LogrusFromContext(ec.Request().Context()).Info("handler")
+		return testHandler(ec)
+	})(ec)
+
+	line := strings.Split(b.String(), "\n")[0]
+
+	if !strings.Contains(line, "id=123") || !strings.Contains(line, `route="/foo/:id"`) {
+		t.Errorf("invalid log: expect request fields, got '%s'", line)
+	}
+}
+
+func TestLogrusFromContextFallback(t *testing.T) {
+	if LogrusFromContext(context.Background()).Logger != logrus.StandardLogger() {
+		t.Errorf("expect default config logger")
+	}
+}
+
+func TestLogrusWithRecover(t *testing.T) {
+	ec := panicCtx(t)
+	b := new(bytes.Buffer)
+
+	logger := logrus.New()
+	logger.Out = b
+
+	config := LogrusConfig{
+		Logger:  logger,
+		Recover: true,
+	}
+
+	_ = LogrusWithConfig(config)(testHandler)(ec)
+
+	for _, s := range []string{"level=error", recoverMessage, recoverStackField} {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("invalid log: expect '%s'", s)
+		}
+	}
+}
+
+func TestLogrusWithLogStart(t *testing.T) {
+	ec := reqCtx(t)
+	b := new(bytes.Buffer)
+
+	logger := logrus.New()
+	logger.Out = b
+
+	config := LogrusConfig{
+		Logger:   logger,
+		LogStart: true,
+	}
+
+	_ = LogrusWithConfig(config)(testHandler)(ec)
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("invalid log: expect 2 lines, got %d", len(lines))
+	}
+
+	if !strings.Contains(lines[0], logStartMessage) || !strings.Contains(lines[1], logMessage) {
+		t.Errorf("invalid log: expect start and completion lines, got '%v'", lines)
+	}
+}
+
+func TestLogrusWithErrorPolicy(t *testing.T) {
+	logger := logrus.New()
+	logger.Out = io.Discard
+
+	testErrorPolicies(t, func(p ErrorPolicy) echo.MiddlewareFunc {
+		return LogrusWithConfig(LogrusConfig{Logger: logger, ErrorPolicy: p})
+	})
+}
+
+func TestLogrusWithConfiguredContextLogger(t *testing.T) {
+	ec := postCtx(t)
+	logger := logrus.New()
+	logger.Out = io.Discard
+
+	_ = LogrusWithConfig(LogrusConfig{Logger: logger})(func(ec echo.Context) error {
+		if LogrusFromContext(ec.Request().Context()).Logger != logger {
+			t.Errorf("invalid log: expect configured logger")
+		}
+
+		return testHandler(ec)
+	})(ec)
+}
+
+func TestLogrusWithLogStartAndSampler(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     func(t *testing.T) echo.Context
+		sampler *Sampler
+		lines   []string
+	}{
+		{"dropped", reqCtx, &Sampler{Rate: 0}, nil},
+		{"kept", reqCtx, &Sampler{Rate: 1}, []string{logStartMessage, logMessage}},
+		{"kept by error", errCtx, &Sampler{Rate: 0, KeepErrors: true}, []string{logMessage}},
+	}
+
+	for _, tt := range tests {
+		b := new(bytes.Buffer)
+
+		logger := logrus.New()
+		logger.Out = b
+
+		config := LogrusConfig{
+			Logger:   logger,
+			LogStart: true,
+			Sampler:  tt.sampler,
+		}
+
+		for i := 0; i < 3; i++ {
+			b.Reset()
+			_ = LogrusWithConfig(config)(testHandler)(tt.ctx(t))
+
+			lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+			if b.Len() == 0 {
+				lines = nil
+			}
+
+			if len(lines) != len(tt.lines) {
+				t.Fatalf("invalid %s: expect %d lines, got '%v'", tt.name, len(tt.lines), lines)
+			}
+
+			for j, msg := range tt.lines {
+				if !strings.Contains(lines[j], msg) {
+					t.Errorf("invalid %s: expect '%s', got '%s'", tt.name, msg, lines[j])
+				}
+			}
+		}
+	}
+}
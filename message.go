@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// templateEmpty replaces the missing tag values of templates.
+const templateEmpty = "-"
+
+// templateSegment defines a piece of a template, a literal text or a tag.
+type templateSegment struct {
+	text string
+	tag  string
+}
+
+// MessageFunc defines a function to build the message of the request log
+// entry from its fields.
+type MessageFunc func(ec echo.Context, fields Fields) string
+
+// messageTemplate renders the log message replacing the placeholders with the
+// tag values (e.g. "{method} {route}" uses @method and @route tags).
+type messageTemplate struct {
+	segments []templateSegment
+	fieldMap map[string]string
+}
+
+// newMessageTemplate parses the template, nil is returned when it is empty.
+// Placeholders are tag names without "@", prefixed tags are supported
+// (e.g. "{header:X-Tenant}"), unclosed and empty braces are kept as text.
+func newMessageTemplate(tmpl string) *messageTemplate {
+	if tmpl == "" {
+		return nil
+	}
+
+	t := &messageTemplate{fieldMap: map[string]string{}}
+
+	for tmpl != "" {
+		start := strings.IndexByte(tmpl, '{')
+		if start < 0 {
+			t.segments = append(t.segments, templateSegment{text: tmpl})
+			break
+		}
+
+		end := strings.IndexByte(tmpl[start:], '}')
+		if end < 0 {
+			t.segments = append(t.segments, templateSegment{text: tmpl})
+			break
+		}
+
+		end += start
+
+		// empty placeholders are kept as text.
+		if end == start+1 {
+			t.segments = append(t.segments, templateSegment{text: tmpl[:end+1]})
+			tmpl = tmpl[end+1:]
+
+			continue
+		}
+
+		if start > 0 {
+			t.segments = append(t.segments, templateSegment{text: tmpl[:start]})
+		}
+
+		tag := "@" + tmpl[start+1:end]
+		t.segments = append(t.segments, templateSegment{tag: tag})
+		t.fieldMap[tag] = tag
+		tmpl = tmpl[end+1:]
+	}
+
+	return t
+}
+
+// render builds the message with the tag values, missing values are
+// replaced by "-".
+func (t *messageTemplate) render(ec echo.Context, tags map[string]interface{}, r *Redactor) string {
+	values := tagFields(ec, t.fieldMap, tags, r)
+
+	var b strings.Builder
+
+	for _, s := range t.segments {
+		if s.tag == "" {
+			b.WriteString(s.text)
+			continue
+		}
+
+		b.WriteString(messageValue(values[s.tag]))
+	}
+
+	return b.String()
+}
+
+// messageValue formats the tag value for the message.
+func messageValue(v interface{}) string {
+	var s string
+
+	switch value := v.(type) {
+	case nil:
+	case []string:
+		s = strings.Join(value, ", ")
+	default:
+		s = fmt.Sprint(value)
+	}
+
+	if s == "" {
+		return templateEmpty
+	}
+
+	return s
+}
+
+// entryMessage resolves the message of the request log entry, the custom
+// message is appended to the slow request and panic messages.
+func entryMessage(ec echo.Context, msg string, tags, fields map[string]interface{}, opts logOptions) string {
+	var custom string
+
+	switch {
+	case opts.messageFunc != nil:
+		custom = opts.messageFunc(ec, fields)
+	case opts.messageTmpl != nil:
+		custom = opts.messageTmpl.render(ec, tags, opts.redactor)
+	default:
+		return msg
+	}
+
+	if msg == logMessage {
+		return custom
+	}
+
+	return msg + ": " + custom
+}
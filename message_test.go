@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestMessageTemplate(t *testing.T) {
+	ec := postCtx(t)
+	tags := mapTags(ec, time.Second, nil)
+
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{"tags", "{method} {host}{path}", "POST some/foo/456"},
+		{"prefixed tags", "user {header:user} from {query:name}", "user admin from john"},
+		{"missing value", "{header:missing}", "-"},
+		{"unclosed brace", "{method} {path", "POST {path"},
+		{"empty braces", "{} {method}", "{} POST"},
+		{"text", "handle", "handle"},
+	}
+
+	for _, tt := range tests {
+		if got := newMessageTemplate(tt.tmpl).render(ec, tags, nil); got != tt.want {
+			t.Errorf("invalid %s: expect '%s', got '%s'", tt.name, tt.want, got)
+		}
+	}
+
+	if newMessageTemplate("") != nil {
+		t.Errorf("expect nil template when it is empty")
+	}
+}
+
+func TestMapFieldsWithMessage(t *testing.T) {
+	opts := logOptions{messageTmpl: newMessageTemplate("{method} {path} {status}")}
+
+	entry, _ := mapFields(postCtx(t), testHandler, opts)
+	if entry.message != "POST /foo/456 200" {
+		t.Errorf("invalid message: got '%s'", entry.message)
+	}
+
+	opts.recoverPanic = true
+	entry, _ = mapFields(panicCtx(t), testHandler, opts)
+
+	if entry.message != "panic recover: GET /some 500" {
+		t.Errorf("invalid recover message: got '%s'", entry.message)
+	}
+}
+
+func TestMapFieldsWithMessageFunc(t *testing.T) {
+	opts := logOptions{
+		fieldMap:    map[string]string{"path": logPath},
+		messageTmpl: newMessageTemplate("{method}"),
+		messageFunc: func(ec echo.Context, fields Fields) string {
+			return "request to " + fields["path"].(string)
+		},
+	}
+
+	entry, _ := mapFields(postCtx(t), testHandler, opts)
+	if entry.message != "request to /foo/456" {
+		t.Errorf("invalid message: got '%s'", entry.message)
+	}
+}
+
+func TestMessageValue(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"nil", messageValue(nil), "-"},
+		{"empty", messageValue(""), "-"},
+		{"values", messageValue([]string{"a", "b"}), "a, b"},
+		{"error", messageValue(errors.New("failed")), "failed"},
+		{"int", messageValue(200), "200"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("invalid %s: expect '%s', got '%s'", tt.name, tt.want, tt.got)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Recover constants.
+const (
+	recoverMessage    = "panic recover"
+	recoverStackField = "stacktrace"
+	recoverStackSize  = 4 << 10 // 4 KB
+)
+
+// recoverFnMessage returns a function building the message of the RecoverFn
+// helpers, the template is rendered with the request tags and appended to the
+// "panic recover" message.
+func recoverFnMessage(tmpl string) func(ec echo.Context) string {
+	t := newMessageTemplate(tmpl)
+	if t == nil {
+		return func(echo.Context) string {
+			return recoverMessage
+		}
+	}
+
+	return func(ec echo.Context) string {
+		return recoverMessage + ": " + t.render(ec, mapTags(ec, 0, nil), nil)
+	}
+}
+
+// callHandler calls the handler, when recovery is enabled the panics are
+// returned as error along with the stack trace. The http.ErrAbortHandler is
+// never recovered, as it is used to abort the response.
+func callHandler(ec echo.Context, h echo.HandlerFunc, recoverPanic bool) (stack []byte, err error) {
+	if recoverPanic {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+
+			if r == http.ErrAbortHandler {
+				panic(r)
+			}
+
+			var ok bool
+			if err, ok = r.(error); !ok {
+				err = fmt.Errorf("%v", r)
+			}
+
+			stack = make([]byte, recoverStackSize)
+			stack = stack[:runtime.Stack(stack, false)]
+		}()
+	}
+
+	return nil, h(ec)
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestMapFieldsWithRecover(t *testing.T) {
+	ec := panicCtx(t)
+	opts := logOptions{
+		fieldMap: map[string]string{
+			"method": logMethod,
+			"status": logStatus,
+			"error":  logError,
+		},
+		sampler:      &Sampler{Rate: 0},
+		recoverPanic: true,
+	}
+
+	entry, err := mapFields(ec, testHandler, opts)
+	if err != nil {
+		t.Errorf("expect panic to be handled, got '%v'", err)
+	}
+
+	if entry.skip {
+		t.Errorf("expect panic entry not to be sampled")
+	}
+
+	if entry.level != LevelError || entry.message != recoverMessage {
+		t.Errorf("invalid entry: level '%v', message '%s'", entry.level, entry.message)
+	}
+
+	if status := ec.Response().Status; status != http.StatusInternalServerError {
+		t.Errorf("expect status 500, got '%d'", status)
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"method", entry.fields["method"], echo.GET},
+		{"status", entry.fields["status"], http.StatusInternalServerError},
+		{"error", fmt.Sprint(entry.fields["error"]), "unable to call"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("invalid %s: expect '%v', got '%v'", tt.name, tt.want, tt.got)
+		}
+	}
+
+	stack, _ := entry.fields[recoverStackField].(string)
+	if !strings.Contains(stack, "testHandler") {
+		t.Errorf("expect stacktrace with the handler, got '%s'", stack)
+	}
+}
+
+func TestMapFieldsWithRecoverError(t *testing.T) {
+	handler := func(echo.Context) error {
+		panic(echo.ErrForbidden)
+	}
+
+	ec := reqCtx(t)
+	_, _ = mapFields(ec, handler, logOptions{recoverPanic: true})
+
+	if status := ec.Response().Status; status != http.StatusForbidden {
+		t.Errorf("expect status 403, got '%d'", status)
+	}
+}
+
+func TestMapFieldsWithRecoverAbortHandler(t *testing.T) {
+	handler := func(echo.Context) error {
+		panic(http.ErrAbortHandler)
+	}
+
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("expect abort handler panic, got '%v'", r)
+		}
+	}()
+
+	_, _ = mapFields(reqCtx(t), handler, logOptions{recoverPanic: true})
+}
+
+func TestMapFieldsWithoutRecover(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expect panic not to be recovered")
+		}
+	}()
+
+	_, _ = mapFields(panicCtx(t), testHandler, logOptions{})
+}
+
+func TestRecoverFnMessage(t *testing.T) {
+	ec := panicCtx(t)
+
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{"empty", "", recoverMessage},
+		{"template", "{method} {uri}", recoverMessage + ": GET /some?panic=1"},
+	}
+
+	for _, tt := range tests {
+		if got := recoverFnMessage(tt.tmpl)(ec); got != tt.want {
+			t.Errorf("invalid %s: expect '%s', got '%s'", tt.name, tt.want, got)
+		}
+	}
+}
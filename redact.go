@@ -0,0 +1,205 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+)
+
+// Redaction constants.
+const (
+	redactedValue   = "[REDACTED]"
+	redactMask      = "*"
+	redactHashPfx   = "sha256:"
+	redactHashSize  = 16
+	redactKeepChars = 4
+)
+
+// RedactMode defines how a sensitive value is masked.
+type RedactMode int
+
+// Redaction modes.
+const (
+	// RedactFull replaces the whole value with [REDACTED].
+	RedactFull RedactMode = iota
+
+	// RedactPartial masks the value keeping only the last 4 characters,
+	// values shorter than 8 characters are fully masked.
+	RedactPartial
+
+	// RedactHash replaces the value with a sha256 hash prefix, allowing the
+	// correlation of values without leaking them.
+	RedactHash
+)
+
+// RedactSource defines the source of values where a rule is applied.
+type RedactSource uint8
+
+// Redaction sources, they can be combined (e.g. RedactHeader|RedactCookie).
+const (
+	RedactHeader RedactSource = 1 << iota
+	RedactQuery
+	RedactForm
+	RedactCookie
+
+	RedactAll = RedactHeader | RedactQuery | RedactForm | RedactCookie
+)
+
+// RedactRule defines a list of keys to be masked.
+type RedactRule struct {
+	// Keys defines the names (case-insensitive) of headers, query params,
+	// form fields or cookies to be masked, response headers are handled as
+	// RedactHeader.
+	Keys []string
+
+	// Sources defines where the rule is applied, the query params embedded
+	// in @uri and @referer are handled as RedactQuery, and the form fields
+	// of URL encoded @body_in as RedactForm. Default: RedactAll.
+	Sources RedactSource
+
+	// Mode defines how the value is masked. Default: RedactFull.
+	Mode RedactMode
+}
+
+// Redactor masks sensitive values before they are logged.
+type Redactor struct {
+	// Rules defines the list of redaction rules, the first matching rule is
+	// applied.
+	Rules []RedactRule
+}
+
+// NewRedactor returns a redactor with the provided rules.
+func NewRedactor(rules ...RedactRule) *Redactor {
+	return &Redactor{Rules: rules}
+}
+
+// Redact masks the value when there is a rule for the key and source.
+func (r *Redactor) Redact(src RedactSource, key, value string) string {
+	if r == nil || value == "" {
+		return value
+	}
+
+	for _, rule := range r.Rules {
+		if !rule.matches(src, key) {
+			continue
+		}
+
+		return redactValue(rule.Mode, value)
+	}
+
+	return value
+}
+
+// RedactValues masks every value when there is a rule for the key and
+// source, a new slice is always returned.
+func (r *Redactor) RedactValues(src RedactSource, key string, values []string) []string {
+	redacted := make([]string, 0, len(values))
+
+	for _, v := range values {
+		redacted = append(redacted, r.Redact(src, key, v))
+	}
+
+	return redacted
+}
+
+// RedactURI masks the query params of the URI based on the RedactQuery rules,
+// the order of params is kept.
+func (r *Redactor) RedactURI(uri string) string {
+	if r == nil {
+		return uri
+	}
+
+	base, query, ok := strings.Cut(uri, "?")
+	if !ok {
+		return uri
+	}
+
+	query, fragment, hasFragment := strings.Cut(query, "#")
+
+	uri = base + "?" + r.redactParams(RedactQuery, query)
+	if hasFragment {
+		uri += "#" + fragment
+	}
+
+	return uri
+}
+
+// redactParams masks the values of URL encoded params (e.g. "a=1&b=2") based
+// on the source rules, the order of params is kept.
+func (r *Redactor) redactParams(src RedactSource, encoded string) string {
+	if r == nil {
+		return encoded
+	}
+
+	params := strings.Split(encoded, "&")
+
+	for i, param := range params {
+		rawKey, rawValue, ok := strings.Cut(param, "=")
+		if !ok {
+			continue
+		}
+
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			continue
+		}
+
+		value, err := url.QueryUnescape(rawValue)
+		if err != nil {
+			value = rawValue
+		}
+
+		if masked := r.Redact(src, key, value); masked != value {
+			params[i] = rawKey + "=" + url.QueryEscape(masked)
+		}
+	}
+
+	return strings.Join(params, "&")
+}
+
+// matches checks if the rule is applied to the key and source.
+func (rule RedactRule) matches(src RedactSource, key string) bool {
+	sources := rule.Sources
+	if sources == 0 {
+		sources = RedactAll
+	}
+
+	if sources&src == 0 {
+		return false
+	}
+
+	for _, k := range rule.Keys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactValue masks the value with the provided mode.
+func redactValue(mode RedactMode, value string) string {
+	switch mode {
+	case RedactPartial:
+		// the runes are counted, so multi-byte characters are not split.
+		runes := []rune(value)
+		if len(runes) < redactKeepChars*2 {
+			return strings.Repeat(redactMask, len(runes))
+		}
+
+		keep := len(runes) - redactKeepChars
+
+		return strings.Repeat(redactMask, keep) + string(runes[keep:])
+	case RedactHash:
+		sum := sha256.Sum256([]byte(value))
+		return redactHashPfx + hex.EncodeToString(sum[:])[:redactHashSize]
+	default:
+		return redactedValue
+	}
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"testing"
+)
+
+func TestRedactorRedact(t *testing.T) {
+	r := NewRedactor(
+		RedactRule{Keys: []string{"Authorization"}, Sources: RedactHeader},
+		RedactRule{Keys: []string{"card"}, Mode: RedactPartial},
+		RedactRule{Keys: []string{"session"}, Sources: RedactCookie, Mode: RedactHash},
+	)
+
+	tests := []struct {
+		name  string
+		src   RedactSource
+		key   string
+		value string
+		want  string
+	}{
+		{"full", RedactHeader, "authorization", "Bearer token", redactedValue},
+		{"other_source", RedactQuery, "authorization", "token", "token"},
+		{"partial", RedactForm, "card", "4111111111111111", "************1111"},
+		{"partial_short", RedactForm, "card", "4111", "****"},
+		{"partial_multibyte", RedactForm, "card", "ñandú-josé", "******josé"},
+		{"partial_multibyte_short", RedactForm, "card", "josé", "****"},
+		{"hash", RedactCookie, "session", "A1B2C3", "sha256:91e45b9dc41b1b0c"},
+		{"not_matched", RedactHeader, "user", "admin", "admin"},
+		{"empty", RedactHeader, "authorization", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Redact(tt.src, tt.key, tt.value); got != tt.want {
+				t.Errorf("expect '%s', got '%s'", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRedactorRedactURI(t *testing.T) {
+	r := NewRedactor(RedactRule{Keys: []string{"access_token"}})
+
+	tests := []struct {
+		name string
+		uri  string
+		want string
+	}{
+		{"without_query", "/some", "/some"},
+		{"not_matched", "/some?name=john", "/some?name=john"},
+		{"matched", "/some?name=john&access_token=abc&id=1", "/some?name=john&access_token=%5BREDACTED%5D&id=1"},
+		{"fragment", "http://foo.bar/?access_token=abc#top", "http://foo.bar/?access_token=%5BREDACTED%5D#top"},
+		{"flag", "/some?debug&access_token=abc", "/some?debug&access_token=%5BREDACTED%5D"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.RedactURI(tt.uri); got != tt.want {
+				t.Errorf("expect '%s', got '%s'", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNilRedactor(t *testing.T) {
+	var r *Redactor
+
+	if got := r.Redact(RedactHeader, "authorization", "token"); got != "token" {
+		t.Errorf("expect value untouched, got '%s'", got)
+	}
+
+	if got := r.RedactURI("/?access_token=abc"); got != "/?access_token=abc" {
+		t.Errorf("expect uri untouched, got '%s'", got)
+	}
+}
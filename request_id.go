@@ -66,10 +66,7 @@ func uuidGen() string {
 
 // requestIDHandler sets the received request-id into request context.
 func requestIDHandler(ec echo.Context, rid string) {
-	req := ec.Request()
-	ctx := context.WithValue(req.Context(), reqIDKey, rid)
-
-	ec.SetRequest(req.WithContext(ctx))
+	withContextValue(ec, reqIDKey, rid)
 }
 
 // RequestIDValue returns the value stored in the context, otherwise returns an
@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Streamed media type, never captured by the response body recorder.
+const mimeEventStream = "text/event-stream"
+
+// responseWriter wraps the echo response writer to record data about the
+// written response.
+type responseWriter struct {
+	http.ResponseWriter
+
+	body    *bytes.Buffer
+	bodyCfg BodyConfig
+	checked bool
+	skip    bool
+
+	start      time.Time
+	firstWrite time.Time
+	lastWrite  time.Time
+	flushes    int
+}
+
+// newResponseWriter wraps the response writer of echo response, use restore
+// to unwrap it.
+func newResponseWriter(res *echo.Response) *responseWriter {
+	w := &responseWriter{
+		ResponseWriter: res.Writer,
+		start:          time.Now(),
+	}
+	res.Writer = w
+
+	return w
+}
+
+// restore sets back the original writer into echo response.
+func (w *responseWriter) restore(res *echo.Response) {
+	res.Writer = w.ResponseWriter
+}
+
+// recordBody enables the body capture.
+func (w *responseWriter) recordBody(cfg BodyConfig) {
+	w.body = new(bytes.Buffer)
+	w.bodyCfg = cfg
+}
+
+// WriteHeader sends the HTTP response header and records the time.
+func (w *responseWriter) WriteHeader(code int) {
+	w.recordWrite()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes the data to the connection and records it.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.recordWrite()
+
+	if w.body != nil {
+		w.captureBody(b)
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.lastWrite = time.Now()
+
+	return n, err
+}
+
+// FlushError flushes buffered data to the client and counts it, it is used by
+// the http.ResponseController.
+func (w *responseWriter) FlushError() error {
+	w.flushes++
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Flush flushes buffered data to the client and counts it, it implements the
+// http.Flusher interface.
+func (w *responseWriter) Flush() {
+	_ = w.FlushError()
+}
+
+// Hijack lets the handler take over the connection, it implements the
+// http.Hijacker interface.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the original http.ResponseWriter, allowing the
+// http.ResponseController to access it.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// recordWrite records the time of the first write.
+func (w *responseWriter) recordWrite() {
+	if w.firstWrite.IsZero() {
+		w.firstWrite = time.Now()
+	}
+}
+
+// durations returns the time to first byte and the time spent writing the
+// response, zero when nothing was written.
+func (w *responseWriter) durations() (ttfb, writing time.Duration) {
+	if w.firstWrite.IsZero() {
+		return 0, 0
+	}
+
+	ttfb = w.firstWrite.Sub(w.start)
+
+	if w.lastWrite.After(w.firstWrite) {
+		writing = w.lastWrite.Sub(w.firstWrite)
+	}
+
+	return ttfb, writing
+}
+
+// captureBody records the written data up to the limit, binary and streamed
+// content types are skipped.
+func (w *responseWriter) captureBody(b []byte) {
+	if !w.checked {
+		w.checked = true
+
+		ct := w.Header().Get(echo.HeaderContentType)
+		w.skip = !allowedContentType(ct, w.bodyCfg.ContentTypes) ||
+			allowedContentType(ct, []string{mimeEventStream})
+	}
+
+	if w.skip {
+		return
+	}
+
+	// one extra byte is kept to identify the truncation.
+	remaining := w.bodyCfg.Limit + 1 - w.body.Len()
+	if remaining <= 0 {
+		return
+	}
+
+	if len(b) > remaining {
+		b = b[:remaining]
+	}
+
+	w.body.Write(b)
+}
+
+// bodyValue returns the captured response body.
+func (w *responseWriter) bodyValue() string {
+	if w.body == nil {
+		return ""
+	}
+
+	return bodyValue(w.body.Bytes(), w.bodyCfg.Limit)
+}
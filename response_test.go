@@ -0,0 +1,161 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestResponseWriterRecordBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []string
+		limit       int
+		want        string
+	}{
+		{"json", echo.MIMEApplicationJSON, []string{`{"id":`, `1}`}, 0, `{"id":1}`},
+		{"truncated", echo.MIMETextPlain, []string{"hello ", "world"}, 8, "hello wo" + bodyTruncated},
+		{"binary", echo.MIMEOctetStream, []string{"binary"}, 0, ""},
+		{"stream", mimeEventStream, []string{"data: 1\n\n"}, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ec := reqCtx(t)
+			res := ec.Response()
+
+			rw := newResponseWriter(res)
+			rw.recordBody(bodyConfig(BodyConfig{Limit: tt.limit}))
+
+			res.Header().Set(echo.HeaderContentType, tt.contentType)
+			res.WriteHeader(http.StatusOK)
+
+			for _, b := range tt.body {
+				_, _ = res.Write([]byte(b))
+			}
+
+			rw.restore(res)
+
+			if got := rw.bodyValue(); got != tt.want {
+				t.Errorf("expect body '%s', got '%s'", tt.want, got)
+			}
+
+			if res.Writer == rw {
+				t.Errorf("expect original writer restored")
+			}
+
+			if res.Size != int64(len(strings.Join(tt.body, ""))) {
+				t.Errorf("expect the whole body written, got '%d' bytes", res.Size)
+			}
+		})
+	}
+}
+
+func TestResponseWriterWithoutBody(t *testing.T) {
+	rw := newResponseWriter(reqCtx(t).Response())
+
+	if got := rw.bodyValue(); got != "" {
+		t.Errorf("expect empty body, got '%s'", got)
+	}
+
+	if rw.Unwrap() == nil {
+		t.Errorf("expect wrapped writer")
+	}
+}
+
+func TestResponseWriterDurations(t *testing.T) {
+	ec := reqCtx(t)
+	res := ec.Response()
+	rw := newResponseWriter(res)
+
+	if ttfb, writing := rw.durations(); ttfb != 0 || writing != 0 {
+		t.Errorf("expect zero durations without writes, got '%s' and '%s'", ttfb, writing)
+	}
+
+	time.Sleep(time.Millisecond)
+	res.WriteHeader(http.StatusOK)
+	_, _ = res.Write([]byte("data: 1\n\n"))
+	res.Flush()
+
+	time.Sleep(time.Millisecond)
+	_, _ = res.Write([]byte("data: 2\n\n"))
+	res.Flush()
+
+	rw.restore(res)
+
+	ttfb, writing := rw.durations()
+
+	if ttfb < time.Millisecond {
+		t.Errorf("expect ttfb greater than 1ms, got '%s'", ttfb)
+	}
+
+	if writing < time.Millisecond {
+		t.Errorf("expect write duration greater than 1ms, got '%s'", writing)
+	}
+
+	if rw.flushes != 2 {
+		t.Errorf("expect 2 flushes, got '%d'", rw.flushes)
+	}
+}
+
+// hijackRecorder is a response recorder supporting connection hijacking.
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+
+	conn net.Conn
+}
+
+func (r *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return r.conn, nil, nil
+}
+
+func TestResponseWriterFlusher(t *testing.T) {
+	ec := reqCtx(t)
+	res := ec.Response()
+	rw := newResponseWriter(res)
+
+	flusher, ok := res.Writer.(http.Flusher)
+	if !ok {
+		t.Fatalf("expect response writer to implement http.Flusher")
+	}
+
+	flusher.Flush()
+
+	if rw.flushes != 1 {
+		t.Errorf("expect flush to be counted, got %d", rw.flushes)
+	}
+}
+
+func TestResponseWriterHijacker(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	ec := reqCtx(t)
+	res := ec.Response()
+	res.Writer = &hijackRecorder{httptest.NewRecorder(), server}
+
+	newResponseWriter(res)
+
+	hijacker, ok := res.Writer.(http.Hijacker)
+	if !ok {
+		t.Fatalf("expect response writer to implement http.Hijacker")
+	}
+
+	conn, _, err := hijacker.Hijack()
+	if err != nil || conn != server {
+		t.Errorf("expect hijacked connection, got '%v'", err)
+	}
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Number of hash bits used by the sample ratio, the float64 mantissa size.
+const sampleHashBits = 53
+
+// Sampler defines which requests are logged, reducing the log volume of high
+// traffic services. The requests are sampled by the request id, so services
+// sharing the same id and rate keep or drop the same requests.
+type Sampler struct {
+	// Rate defines the ratio of requests logged, between 0 (none) and 1
+	// (all).
+	Rate float64
+
+	// Routes overrides the rate by route (e.g. "/users/:id").
+	Routes map[string]float64
+
+	// KeepErrors always logs requests that returned an error or a 5xx
+	// status code.
+	KeepErrors bool
+
+	// KeepSlowerThan always logs requests with latency greater than or
+	// equal to the duration.
+	KeepSlowerThan time.Duration
+
+	// KeepStatus always logs requests with one of the status codes.
+	KeepStatus []int
+
+	dropped atomic.Uint64
+}
+
+// Dropped returns the number of log entries dropped by the sampler.
+func (s *Sampler) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// sampled checks if the request is kept by the rate, the decision only
+// depends on the request id and route. When it is taken before calling the
+// handler, the id set by a later RequestID middleware is not available yet.
+func (s *Sampler) sampled(ec echo.Context) bool {
+	if s == nil {
+		return true
+	}
+
+	rate := s.Rate
+	if r, ok := s.Routes[ec.Path()]; ok {
+		rate = r
+	}
+
+	return sampleRatio(requestID(ec)) < rate
+}
+
+// sample checks if the request must be logged, based on the rate decision and
+// the always-keep rules, otherwise it is counted as dropped.
+func (s *Sampler) sample(ec echo.Context, err error, latency time.Duration, sampled bool) bool {
+	if s == nil || sampled || s.keep(ec, err, latency) {
+		return true
+	}
+
+	s.dropped.Add(1)
+
+	return false
+}
+
+// keep checks the always-keep rules.
+func (s *Sampler) keep(ec echo.Context, err error, latency time.Duration) bool {
+	status := ec.Response().Status
+
+	if s.KeepErrors && (err != nil || status >= http.StatusInternalServerError) {
+		return true
+	}
+
+	if s.KeepSlowerThan > 0 && latency >= s.KeepSlowerThan {
+		return true
+	}
+
+	return slices.Contains(s.KeepStatus, status)
+}
+
+// sampleRatio returns a deterministic value in [0, 1) for the request id, or
+// a random one when the id is empty.
+func sampleRatio(id string) float64 {
+	if id == "" {
+		return rand.Float64() //nolint:gosec
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(id))
+
+	return hashRatio(h.Sum64())
+}
+
+// hashRatio maps the hash to [0, 1), only the 53 most significant bits are
+// used as they are exactly represented by a float64.
+func hashRatio(sum uint64) float64 {
+	return float64(sum>>(64-sampleHashBits)) / (1 << sampleHashBits)
+}
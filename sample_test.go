@@ -0,0 +1,123 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSamplerSample(t *testing.T) {
+	tests := []struct {
+		name    string
+		sampler *Sampler
+		status  int
+		err     error
+		latency time.Duration
+		want    bool
+	}{
+		{"nil", nil, http.StatusOK, nil, 0, true},
+		{"drop_all", &Sampler{}, http.StatusOK, nil, 0, false},
+		{"keep_all", &Sampler{Rate: 1}, http.StatusOK, nil, 0, true},
+		{"route_rate", &Sampler{Routes: map[string]float64{"/some": 1}}, http.StatusOK, nil, 0, true},
+		{"keep_errors", &Sampler{KeepErrors: true}, http.StatusOK, errors.New("error"), 0, true},
+		{"keep_server_errors", &Sampler{KeepErrors: true}, http.StatusBadGateway, nil, 0, true},
+		{"keep_slower", &Sampler{KeepSlowerThan: time.Second}, http.StatusOK, nil, time.Second, true},
+		{"not_slower", &Sampler{KeepSlowerThan: time.Second}, http.StatusOK, nil, time.Millisecond, false},
+		{"keep_status", &Sampler{KeepStatus: []int{http.StatusNotFound}}, http.StatusNotFound, nil, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ec := reqCtx(t)
+			ec.SetPath("/some")
+			ec.Response().Status = tt.status
+
+			if got := tt.sampler.sample(ec, tt.err, tt.latency, tt.sampler.sampled(ec)); got != tt.want {
+				t.Errorf("expect sample '%v', got '%v'", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSamplerDropped(t *testing.T) {
+	s := &Sampler{}
+	ec := reqCtx(t)
+
+	for i := 0; i < 3; i++ {
+		_ = s.sample(ec, nil, 0, s.sampled(ec))
+	}
+
+	if s.Dropped() != 3 {
+		t.Errorf("expect 3 dropped entries, got '%d'", s.Dropped())
+	}
+}
+
+func TestSamplerByRequestID(t *testing.T) {
+	s := &Sampler{Rate: 0.5}
+	ec := reqCtx(t)
+	ec.Request().Header.Set(echo.HeaderXRequestID, "4a1c1b7e-9e6f-4f4b-8f0e-0d1b0a6c5e21")
+
+	want := s.sampled(ec)
+
+	for i := 0; i < 10; i++ {
+		if got := s.sampled(ec); got != want {
+			t.Fatalf("expect deterministic sampling by request id")
+		}
+	}
+}
+
+func TestSampleRatio(t *testing.T) {
+	for _, id := range []string{"", "1", "abc", "4a1c1b7e-9e6f-4f4b-8f0e-0d1b0a6c5e21"} {
+		if r := sampleRatio(id); r < 0 || r >= 1 {
+			t.Errorf("expect ratio in [0, 1), got '%f'", r)
+		}
+	}
+}
+
+func TestHashRatio(t *testing.T) {
+	for _, sum := range []uint64{0, 1, 1 << 63, math.MaxUint64 - 1, math.MaxUint64} {
+		if r := hashRatio(sum); r < 0 || r >= 1 {
+			t.Errorf("expect ratio in [0, 1) for '%d', got '%f'", sum, r)
+		}
+	}
+}
+
+func TestSamplerWithLaterRequestID(t *testing.T) {
+	b := new(bytes.Buffer)
+
+	logger := logrus.New()
+	logger.Out = b
+
+	s := &Sampler{Rate: 0.5}
+
+	e := echo.New()
+	e.Use(LogrusWithConfig(LogrusConfig{Logger: logger, Sampler: s}))
+	e.Use(RequestID())
+	e.GET("/", func(ec echo.Context) error {
+		return ec.NoContent(http.StatusOK)
+	})
+
+	for i := 0; i < 100; i++ {
+		b.Reset()
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		id := rec.Header().Get(echo.HeaderXRequestID)
+		if want, got := sampleRatio(id) < s.Rate, b.Len() > 0; want != got {
+			t.Fatalf("invalid sample of '%s': expect '%v', got '%v'", id, want, got)
+		}
+	}
+}
@@ -0,0 +1,350 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	mw "github.com/labstack/echo/v4/middleware"
+)
+
+// SlogLogConfig defines the config for standard library Slog middleware.
+type SlogLogConfig struct {
+	// FieldMap set a list of fields with tags
+	//
+	// Tags to constructed the logger fields.
+	//
+	// - @id (Request ID)
+	// - @remote_ip (Client IP, see IPExtractor)
+	// - @peer_ip (Connection peer IP)
+	// - @forwarded_chain (Proxy hops of Forwarded or X-Forwarded-For)
+	// - @uri
+	// - @host
+	// - @method
+	// - @path
+	// - @route
+	// - @protocol
+	// - @referer
+	// - @user_agent
+	// - @ua_browser (Browser name parsed from the User-Agent)
+	// - @ua_browser_version (Browser major version)
+	// - @ua_os (Operating system name)
+	// - @ua_device_type (desktop, mobile, tablet, bot or Other)
+	// - @ua_is_bot (Crawlers, monitors and http clients)
+	// - @status
+	// - @error
+	// - @error_code (HTTP error code)
+	// - @error_message (HTTP error message or error string)
+	// - @error_internal (Internal error of HTTP errors)
+	// - @error_type (Go type of the error)
+	// - @error_chain (Messages of the wrapped errors)
+	// - @error_stack (Stack trace, when the error provides one)
+	// - @latency (In nanoseconds, see LatencyUnit for typed values)
+	// - @latency_human (Human readable)
+	// - @bytes_in (Bytes received)
+	// - @bytes_out (Bytes sent)
+	// - @body_in (Request body, see RequestBody)
+	// - @body_out (Response body, see ResponseBody)
+	// - @ttfb (Time to first byte, in nanoseconds)
+	// - @write_duration (Time spent writing the response, in nanoseconds)
+	// - @flush_count (Number of response flushes)
+	// - @start_time (Request start time, see TimeFormat)
+	// - @end_time (Request end time, see TimeFormat)
+	// - @header:<NAME>
+	// - @query:<NAME>
+	// - @form:<NAME>
+	// - @cookie:<NAME>
+	// - @header[]:<NAME> (All values of the header)
+	// - @query[]:<NAME> (All values of the query param)
+	// - @form[]:<NAME> (All values of the form field)
+	// - @param:<NAME> (Path parameter)
+	// - @ctx:<KEY> (Value stored with echo.Context Set)
+	// - @res_header:<NAME> (Response header)
+	//
+	// Custom tags can be added with `RegisterTag()` and `RegisterTagPrefix()`.
+	FieldMap map[string]string
+
+	// ContextFieldMap set a list of fields with tags added to the logger
+	// stored in the request context, see `SlogLogFromContext()`. When it is
+	// empty the Logger is stored as is (e.g. RequestContextFieldMap).
+	ContextFieldMap map[string]string
+
+	// RequestBody defines how the request body is captured by the @body_in
+	// tag.
+	RequestBody BodyConfig
+
+	// ResponseBody defines how the response body is captured by the
+	// @body_out tag.
+	ResponseBody BodyConfig
+
+	// Redactor masks sensitive values of headers, query params, form fields
+	// and cookies, including the query params of @uri and @referer.
+	Redactor *Redactor
+
+	// Sampler defines which requests are logged, by default all requests are
+	// logged.
+	Sampler *Sampler
+
+	// TypedValues logs @latency, @bytes_in and @bytes_out as numbers instead
+	// of strings.
+	TypedValues bool
+
+	// LatencyUnit defines the unit of @latency when TypedValues is enabled.
+	// Default: LatencyNanoseconds.
+	LatencyUnit LatencyUnit
+
+	// NestedFields converts dotted FieldMap keys (e.g. "http.request.method")
+	// into nested objects.
+	NestedFields bool
+
+	// TimeFormat defines the format of @start_time and @end_time, a time
+	// layout or one of the TimeFormatUnix constants.
+	// Default: time.RFC3339Nano.
+	TimeFormat string
+
+	// TimeUTC converts @start_time and @end_time to UTC, otherwise the local
+	// time is used.
+	TimeUTC bool
+
+	// Async emits the log entries in background workers, by default they are
+	// emitted synchronously.
+	Async *AsyncLogger
+
+	// Processors defines a chain of functions applied on the log fields
+	// before the entry is emitted.
+	Processors []FieldProcessor
+
+	// Recover recovers from panics in the handler chain, emitting a single
+	// error entry with the FieldMap fields and the stacktrace. The 500
+	// response is sent by the echo error handler.
+	Recover bool
+
+	// SlowRequest logs the requests exceeding a latency threshold with the
+	// "slow request" message and an elevated level.
+	SlowRequest *SlowRequest
+
+	// LogStart emits a "request started" entry before calling the handler,
+	// with the FieldMap tags known at that point (e.g. @id, @method, @uri).
+	// The response, error, form and body tags are not logged, and the
+	// entry is only emitted when the request is kept by the Sampler rate.
+	LogStart bool
+
+	// Message defines a template of the request entry message, the
+	// placeholders are tag names without "@" (e.g. "{method} {route}
+	// {status} in {latency_human}"). Default: "handle request".
+	Message string
+
+	// MessageFunc builds the request entry message from the log fields, it
+	// has precedence over Message.
+	MessageFunc MessageFunc
+
+	// ErrorPolicy defines how the handler error is handled and propagated.
+	// Default: ErrorHandleAndPropagate.
+	ErrorPolicy ErrorPolicy
+
+	// IPExtractor defines how the client IP of @remote_ip is extracted
+	// (e.g. `echo.ExtractIPDirect()`, `ExtractIPFromForwardedHeader()`), by
+	// default the echo.Context RealIP is used.
+	IPExtractor echo.IPExtractor
+
+	// Level defines a function to resolve the log level of each request,
+	// when it is not defined the requests are logged as info.
+	Level LevelFunc
+
+	// Logger it is a slog logger, any slog.Handler can be used.
+	Logger *slog.Logger
+
+	// Skipper defines a function to skip middleware.
+	Skipper mw.Skipper
+}
+
+// slogLogKey key used to store the request-scoped logger in context.
+var slogLogKey = &ctxkey{"slog-logger"}
+
+// DefaultSlogLogConfig is the default Slog middleware config.
+var DefaultSlogLogConfig = SlogLogConfig{
+	FieldMap: defaultFields,
+	Logger:   slog.Default(),
+	Skipper:  mw.DefaultSkipper,
+}
+
+// SlogLog returns a middleware that logs HTTP requests.
+func SlogLog() echo.MiddlewareFunc {
+	return SlogLogWithConfig(DefaultSlogLogConfig)
+}
+
+// SlogLogWithConfig returns a Slog middleware with config.
+// See: `SlogLog()`.
+func SlogLogWithConfig(cfg SlogLogConfig) echo.MiddlewareFunc {
+	// Defaults
+	if cfg.Skipper == nil {
+		cfg.Skipper = DefaultSlogLogConfig.Skipper
+	}
+
+	if cfg.Logger == nil {
+		cfg.Logger = DefaultSlogLogConfig.Logger
+	}
+
+	if len(cfg.FieldMap) == 0 {
+		cfg.FieldMap = DefaultSlogLogConfig.FieldMap
+	}
+
+	opts := logOptions{
+		fieldMap:     cfg.FieldMap,
+		level:        cfg.Level,
+		requestBody:  cfg.RequestBody,
+		responseBody: cfg.ResponseBody,
+		redactor:     cfg.Redactor,
+		sampler:      cfg.Sampler,
+		typedValues:  cfg.TypedValues,
+		latencyUnit:  cfg.LatencyUnit,
+		nestedFields: cfg.NestedFields,
+		timeFormat:   cfg.TimeFormat,
+		timeUTC:      cfg.TimeUTC,
+		processors:   cfg.Processors,
+		recoverPanic: cfg.Recover,
+		slowRequest:  cfg.SlowRequest,
+		messageTmpl:  newMessageTemplate(cfg.Message),
+		messageFunc:  cfg.MessageFunc,
+		errorPolicy:  cfg.ErrorPolicy,
+		ipExtractor:  cfg.IPExtractor,
+	}
+
+	if cfg.LogStart {
+		opts.logStart = func(ec echo.Context, start logEntry) {
+			ctx := ec.Request().Context()
+
+			cfg.Async.emit(func() {
+				slogLogEntry(ctx, cfg.Logger, start)
+			})
+		}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ec echo.Context) (err error) {
+			if cfg.Skipper(ec) {
+				return next(ec)
+			}
+
+			logger := cfg.Logger
+			if len(cfg.ContextFieldMap) > 0 {
+				fields := requestFields(ec, cfg.ContextFieldMap, opts)
+				logger = slog.New(logger.Handler().WithAttrs(slogAttrs(fields)))
+			}
+
+			withContextValue(ec, slogLogKey, logger)
+
+			entry, err := mapFields(ec, next, opts)
+			if !entry.skip {
+				ctx := ec.Request().Context()
+
+				cfg.Async.emit(func() {
+					slogLogEntry(ctx, cfg.Logger, entry)
+				})
+			}
+
+			return
+		}
+	}
+}
+
+// SlogLogRecoverFn returns a Slog recover log function to print panic errors.
+func SlogLogRecoverFn(logger *slog.Logger) mw.LogErrorFunc {
+	return SlogLogRecoverFnWithMessage(logger, "")
+}
+
+// SlogLogRecoverFnWithMessage returns a Slog recover log function to print
+// panic errors with the message template appended to "panic recover".
+//
+// The template uses the placeholders of the Message config (e.g. "{method}
+// {uri}"), the response tags are not resolved at this point.
+func SlogLogRecoverFnWithMessage(logger *slog.Logger, tmpl string) mw.LogErrorFunc {
+	message := recoverFnMessage(tmpl)
+
+	return func(ec echo.Context, err error, stack []byte) error {
+		logger.LogAttrs(
+			ec.Request().Context(),
+			slog.LevelError,
+			message(ec),
+			slog.String(recoverStackField, string(stack)),
+			slog.Any("error", err),
+		)
+
+		return err
+	}
+}
+
+// SlogLogFromContext returns the request-scoped logger stored by SlogLog
+// middleware, otherwise returns the default config logger (e.g. outside
+// the middleware chain).
+func SlogLogFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(slogLogKey).(*slog.Logger); ok {
+		return logger
+	}
+
+	return DefaultSlogLogConfig.Logger
+}
+
+// slogLogEntry emits the log entry using slog logger.
+func slogLogEntry(ctx context.Context, logger *slog.Logger, entry logEntry) {
+	logger.LogAttrs(
+		ctx,
+		slogLevel(entry.level),
+		entry.message,
+		slogAttrs(entry.fields)...,
+	)
+}
+
+// slogLevel converts the log level into slog level.
+func slogLevel(lvl Level) slog.Level {
+	switch lvl {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// slogAttrs converts the log fields into slog attributes, nested maps are
+// converted into slog groups.
+func slogAttrs(fields map[string]interface{}) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(fields))
+
+	for k, v := range fields {
+		attrs = append(attrs, slogAttr(k, v))
+	}
+
+	return attrs
+}
+
+// slogAttr converts a single field into a slog attribute.
+func slogAttr(k string, v interface{}) slog.Attr {
+	if group, ok := v.(map[string]interface{}); ok {
+		return slog.Attr{Key: k, Value: slog.GroupValue(slogAttrs(group)...)}
+	}
+
+	switch val := v.(type) {
+	case string:
+		return slog.String(k, val)
+	case int:
+		return slog.Int(k, val)
+	case int64:
+		return slog.Int64(k, val)
+	case float64:
+		return slog.Float64(k, val)
+	case time.Duration:
+		return slog.Duration(k, val)
+	default:
+		return slog.Any(k, v)
+	}
+}
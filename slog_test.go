@@ -0,0 +1,308 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	emw "github.com/labstack/echo/v4/middleware"
+)
+
+// slogEntries decodes every JSON line written by a slog JSON handler.
+func slogEntries(t *testing.T, b *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	entries := []map[string]interface{}{}
+
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		entry := map[string]interface{}{}
+
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid log: %v", err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func TestSlogLogWithConfig(t *testing.T) {
+	ec := postCtx(t)
+	b := new(bytes.Buffer)
+
+	config := SlogLogConfig{
+		Logger:   slog.New(slog.NewJSONHandler(b, nil)),
+		FieldMap: testFields,
+	}
+
+	_ = SlogLogWithConfig(config)(testHandler)(ec)
+
+	entry := slogEntries(t, b)[0]
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"msg", entry["msg"], "handle request"},
+		{"level", entry["level"], "INFO"},
+		{"id", entry["id"], "123"},
+		{"remote_ip", entry["remote_ip"], "http://foo.bar"},
+		{"uri", entry["uri"], "http://some/foo/456?name=john"},
+		{"host", entry["host"], "some"},
+		{"method", entry["method"], "POST"},
+		{"status", entry["status"], float64(http.StatusOK)},
+		{"bytes_in", entry["bytes_in"], "0"},
+		{"bytes_out", entry["bytes_out"], "4"},
+		{"path", entry["path"], "/foo/456"},
+		{"route", entry["route"], "/foo/:id"},
+		{"protocol", entry["protocol"], "HTTP/1.1"},
+		{"referer", entry["referer"], "http://foo.bar"},
+		{"user_agent", entry["user_agent"], "cli-agent"},
+		{"user", entry["user"], "admin"},
+		{"filter_name", entry["filter_name"], "john"},
+		{"username", entry["username"], "doejohn"},
+		{"session", entry["session"], "A1B2C3"},
+		{"body_in", entry["body_in"], "username=doejohn"},
+		{"body_out", entry["body_out"], "test"},
+		{"param_id", entry["param_id"], "456"},
+		{"res_id", entry["res_id"], "123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("entry_%s", tt.name), func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("expect '%s' as '%v', got '%v'", tt.name, tt.want, tt.got)
+			}
+		})
+	}
+}
+
+func TestSlogLog(t *testing.T) {
+	ec := reqCtx(t)
+	_ = SlogLog()(testHandler)(ec)
+}
+
+func TestSlogLogWithEmptyConfig(t *testing.T) {
+	ec := reqCtx(t)
+	_ = SlogLogWithConfig(SlogLogConfig{})(testHandler)(ec)
+}
+
+func TestSlogLogWithSkipper(t *testing.T) {
+	ec := reqCtx(t)
+
+	config := DefaultSlogLogConfig
+	config.Skipper = func(echo.Context) bool {
+		return true
+	}
+
+	_ = SlogLogWithConfig(config)(testHandler)(ec)
+}
+
+func TestSlogLogRetrievesAnError(t *testing.T) {
+	ec := errCtx(t)
+	b := new(bytes.Buffer)
+
+	config := SlogLogConfig{
+		Logger: slog.New(slog.NewJSONHandler(b, nil)),
+	}
+
+	_ = SlogLogWithConfig(config)(testHandler)(ec)
+
+	entry := slogEntries(t, b)[0]
+
+	if entry["status"] != float64(http.StatusInternalServerError) {
+		t.Errorf("invalid log: wrong status code")
+	}
+
+	if _, ok := entry["error"]; !ok {
+		t.Errorf("invalid log: error not found")
+	}
+}
+
+func TestSlogLogRecoverFn(t *testing.T) {
+	ec := panicCtx(t)
+	b := new(bytes.Buffer)
+	logger := slog.New(slog.NewJSONHandler(b, nil))
+
+	rec := emw.RecoverWithConfig(emw.RecoverConfig{
+		LogErrorFunc: SlogLogRecoverFn(logger),
+	})
+
+	config := SlogLogConfig{
+		Logger: logger,
+	}
+
+	_ = SlogLogWithConfig(config)(rec(testHandler))(ec)
+
+	entries := slogEntries(t, b)
+
+	if entries[0]["error"] != "unable to call" {
+		t.Errorf("invalid log: error not found")
+	}
+
+	if _, ok := entries[0]["stacktrace"]; !ok {
+		t.Errorf("invalid log: stacktrace not found")
+	}
+
+	if entries[1]["status"] != float64(http.StatusInternalServerError) {
+		t.Errorf("invalid log: wrong status code")
+	}
+}
+
+func TestSlogAttrGroup(t *testing.T) {
+	attr := slogAttr("http", map[string]interface{}{
+		"method": "GET",
+	})
+
+	if attr.Value.Kind() != slog.KindGroup {
+		t.Fatalf("expect group attribute, got '%v'", attr.Value.Kind())
+	}
+
+	group := attr.Value.Group()
+
+	if len(group) != 1 || group[0].Key != "method" || group[0].Value.String() != "GET" {
+		t.Errorf("invalid group: '%v'", group)
+	}
+}
+
+func TestSlogLogWithLevel(t *testing.T) {
+	ec := errCtx(t)
+	b := new(bytes.Buffer)
+
+	config := SlogLogConfig{
+		Logger: slog.New(slog.NewJSONHandler(b, nil)),
+		Level:  StatusLevel,
+	}
+
+	_ = SlogLogWithConfig(config)(testHandler)(ec)
+
+	if lvl := slogEntries(t, b)[0]["level"]; lvl != "ERROR" {
+		t.Errorf("invalid log: expect error level, got '%v'", lvl)
+	}
+}
+
+func TestSlogLogWithTypedValues(t *testing.T) {
+	ec := postCtx(t)
+	b := new(bytes.Buffer)
+
+	config := SlogLogConfig{
+		Logger:      slog.New(slog.NewJSONHandler(b, nil)),
+		FieldMap:    testFields,
+		TypedValues: true,
+		LatencyUnit: LatencySeconds,
+	}
+
+	_ = SlogLogWithConfig(config)(testHandler)(ec)
+
+	entry := slogEntries(t, b)[0]
+
+	if entry["bytes_out"] != float64(4) {
+		t.Errorf("invalid log: expect bytes_out as number, got '%T'", entry["bytes_out"])
+	}
+
+	if _, ok := entry["latency"].(float64); !ok {
+		t.Errorf("invalid log: expect latency as number, got '%T'", entry["latency"])
+	}
+}
+
+func TestSlogLogWithNestedFields(t *testing.T) {
+	ec := postCtx(t)
+	b := new(bytes.Buffer)
+
+	config := SlogLogConfig{
+		Logger: slog.New(slog.NewJSONHandler(b, nil)),
+		FieldMap: map[string]string{
+			"http.request.method": logMethod,
+		},
+		NestedFields: true,
+	}
+
+	_ = SlogLogWithConfig(config)(testHandler)(ec)
+
+	if !strings.Contains(b.String(), `"http":{"request":{"method":"POST"}}`) {
+		t.Errorf("invalid log: expect nested fields, got '%s'", b.String())
+	}
+}
+
+func TestSlogLogWithContextLogger(t *testing.T) {
+	ec := postCtx(t)
+	b := new(bytes.Buffer)
+
+	config := SlogLogConfig{
+		Logger:          slog.New(slog.NewJSONHandler(b, nil)),
+		ContextFieldMap: RequestContextFieldMap,
+	}
+
+	_ = SlogLogWithConfig(config)(func(ec echo.Context) error {
+		SlogLogFromContext(ec.Request().Context()).Info("handler")
+		return testHandler(ec)
+	})(ec)
+
+	entry := slogEntries(t, b)[0]
+
+	if entry["id"] != "123" || entry["route"] != "/foo/:id" {
+		t.Errorf("invalid log: expect request fields, got '%v'", entry)
+	}
+}
+
+func TestSlogLogFromContextFallback(t *testing.T) {
+	if SlogLogFromContext(context.Background()) != DefaultSlogLogConfig.Logger {
+		t.Errorf("expect default config logger")
+	}
+}
+
+func TestSlogLogWithRecover(t *testing.T) {
+	ec := panicCtx(t)
+	b := new(bytes.Buffer)
+
+	config := SlogLogConfig{
+		Logger:  slog.New(slog.NewJSONHandler(b, nil)),
+		Recover: true,
+	}
+
+	_ = SlogLogWithConfig(config)(testHandler)(ec)
+
+	entry := slogEntries(t, b)[0]
+	if entry["level"] != "ERROR" || entry["msg"] != recoverMessage {
+		t.Errorf("invalid log: expect panic entry, got '%v'", entry)
+	}
+
+	if _, ok := entry[recoverStackField]; !ok {
+		t.Errorf("invalid log: expect stacktrace field")
+	}
+}
+
+func TestSlogLogWithErrorPolicy(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	testErrorPolicies(t, func(p ErrorPolicy) echo.MiddlewareFunc {
+		return SlogLogWithConfig(SlogLogConfig{Logger: logger, ErrorPolicy: p})
+	})
+}
+
+func TestSlogLogWithConfiguredContextLogger(t *testing.T) {
+	ec := postCtx(t)
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	_ = SlogLogWithConfig(SlogLogConfig{Logger: logger})(func(ec echo.Context) error {
+		if SlogLogFromContext(ec.Request().Context()) != logger {
+			t.Errorf("invalid log: expect configured logger")
+		}
+
+		return testHandler(ec)
+	})(ec)
+}
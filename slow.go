@@ -0,0 +1,58 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Slow request constants.
+const (
+	slowMessage        = "slow request"
+	slowThresholdField = "slow_threshold"
+)
+
+// SlowRequest defines the latency thresholds to flag slow requests, the
+// exceeded threshold is added to the log fields as "slow_threshold".
+type SlowRequest struct {
+	// Threshold defines the latency from which a request is slow, zero
+	// disables the detection.
+	Threshold time.Duration
+
+	// Routes overrides the threshold by route (e.g. "/users/:id"), zero
+	// disables the detection for the route.
+	Routes map[string]time.Duration
+
+	// Level defines the minimum level of slow requests, the level resolved
+	// by the log middleware is kept when it is higher. Default: LevelWarn.
+	Level *Level
+}
+
+// exceeded checks if the latency reached the threshold of the request route,
+// returning the threshold.
+func (s *SlowRequest) exceeded(ec echo.Context, latency time.Duration) (time.Duration, bool) {
+	if s == nil {
+		return 0, false
+	}
+
+	threshold := s.Threshold
+	if t, ok := s.Routes[ec.Path()]; ok {
+		threshold = t
+	}
+
+	return threshold, threshold > 0 && latency >= threshold
+}
+
+// level returns the minimum level of slow requests.
+func (s *SlowRequest) level() Level {
+	if s.Level == nil {
+		return LevelWarn
+	}
+
+	return *s.Level
+}
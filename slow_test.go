@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func slowHandler(ec echo.Context) error {
+	time.Sleep(5 * time.Millisecond)
+	return testHandler(ec)
+}
+
+func TestSlowRequestExceeded(t *testing.T) {
+	ec := postCtx(t)
+
+	tests := []struct {
+		name      string
+		slow      *SlowRequest
+		latency   time.Duration
+		threshold time.Duration
+		exceeded  bool
+	}{
+		{"nil", nil, time.Second, 0, false},
+		{"disabled", &SlowRequest{}, time.Second, 0, false},
+		{"below", &SlowRequest{Threshold: time.Second}, time.Millisecond, time.Second, false},
+		{"reached", &SlowRequest{Threshold: time.Second}, time.Second, time.Second, true},
+		{"route", &SlowRequest{
+			Threshold: time.Second,
+			Routes:    map[string]time.Duration{"/foo/:id": time.Millisecond},
+		}, time.Millisecond, time.Millisecond, true},
+		{"route disabled", &SlowRequest{
+			Threshold: time.Millisecond,
+			Routes:    map[string]time.Duration{"/foo/:id": 0},
+		}, time.Second, 0, false},
+	}
+
+	for _, tt := range tests {
+		threshold, exceeded := tt.slow.exceeded(ec, tt.latency)
+
+		if threshold != tt.threshold || exceeded != tt.exceeded {
+			t.Errorf("invalid %s: expect '%v, %v', got '%v, %v'", tt.name, tt.threshold, tt.exceeded, threshold, exceeded)
+		}
+	}
+}
+
+func TestMapFieldsWithSlowRequest(t *testing.T) {
+	opts := logOptions{
+		fieldMap:    map[string]string{"path": logPath},
+		slowRequest: &SlowRequest{Threshold: time.Millisecond},
+	}
+
+	entry, _ := mapFields(reqCtx(t), slowHandler, opts)
+
+	if entry.level != LevelWarn || entry.message != slowMessage {
+		t.Errorf("invalid entry: level '%v', message '%s'", entry.level, entry.message)
+	}
+
+	if entry.fields[slowThresholdField] != "1000000" {
+		t.Errorf("expect slow_threshold field, got '%v'", entry.fields[slowThresholdField])
+	}
+}
+
+func TestMapFieldsWithSlowRequestKeepsHigherLevel(t *testing.T) {
+	level := LevelWarn
+	opts := logOptions{
+		level:        StatusLevel,
+		typedValues:  true,
+		latencyUnit:  LatencyMilliseconds,
+		slowRequest:  &SlowRequest{Threshold: time.Millisecond, Level: &level},
+		nestedFields: true,
+	}
+
+	handler := func(ec echo.Context) error {
+		_ = slowHandler(ec)
+		return echo.ErrInternalServerError
+	}
+
+	entry, _ := mapFields(reqCtx(t), handler, opts)
+
+	if entry.level != LevelError {
+		t.Errorf("expect error level, got '%v'", entry.level)
+	}
+
+	if entry.fields[slowThresholdField] != int64(1) {
+		t.Errorf("expect typed slow_threshold field, got '%v'", entry.fields[slowThresholdField])
+	}
+}
+
+func TestMapFieldsWithFastRequest(t *testing.T) {
+	opts := logOptions{slowRequest: &SlowRequest{Threshold: time.Minute}}
+
+	entry, _ := mapFields(reqCtx(t), testHandler, opts)
+
+	if entry.level != LevelInfo || entry.message != logMessage {
+		t.Errorf("invalid entry: level '%v', message '%s'", entry.level, entry.message)
+	}
+}
+
+func TestSlowRequestLevel(t *testing.T) {
+	info, err := LevelInfo, LevelError
+
+	tests := []struct {
+		name  string
+		level *Level
+		want  Level
+	}{
+		{"default", nil, LevelWarn},
+		{"info", &info, LevelInfo},
+		{"error", &err, LevelError},
+	}
+
+	for _, tt := range tests {
+		if got := (&SlowRequest{Level: tt.level}).level(); got != tt.want {
+			t.Errorf("invalid %s: expect '%v', got '%v'", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestMapFieldsWithSlowRequestKeepingLevel(t *testing.T) {
+	level := LevelInfo
+	opts := logOptions{slowRequest: &SlowRequest{Threshold: time.Millisecond, Level: &level}}
+
+	entry, _ := mapFields(reqCtx(t), slowHandler, opts)
+
+	if entry.level != LevelInfo || entry.message != slowMessage {
+		t.Errorf("invalid entry: level '%v', message '%s'", entry.level, entry.message)
+	}
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TagFunc defines a function to resolve the value of a custom tag.
+type TagFunc func(ec echo.Context) interface{}
+
+// TagPrefixFunc defines a function to resolve the value of a custom prefixed
+// tag, the name after the prefix is provided (e.g. "sub" for "@claim:sub").
+type TagPrefixFunc func(ec echo.Context, name string) interface{}
+
+// builtinTags lists the tags provided by the package.
+var builtinTags = []string{
+	logID, logRemoteIP, logPeerIP, logFwdChain, logURI, logHost, logMethod,
+	logPath, logRoute, logProtocol, logReferer, logUserAgent, logUABrowser,
+	logUABrowserVer, logUAOS, logUADeviceType, logUAIsBot, logStatus,
+	logError, logErrorCode, logErrorMessage, logErrorInternal, logErrorType,
+	logErrorChain, logErrorStack, logLatency, logLatencyHuman, logBytesIn,
+	logBytesOut, logBodyIn, logBodyOut, logTTFB, logWriteDur, logFlushCount,
+	logStartTime, logEndTime, logTimeLocal,
+}
+
+// builtinTagPrefixes lists the tag prefixes provided by the package.
+var builtinTagPrefixes = []string{
+	logHeaderPrefix, logQueryPrefix, logFormPrefix, logCookiePrefix,
+	logParamPrefix, logCtxPrefix, logResHeaderPrefix, logHeadersPrefix,
+	logQueriesPrefix, logFormsPrefix,
+}
+
+// tagRegistry stores the custom tags honored by every log middleware.
+var tagRegistry = struct {
+	sync.RWMutex
+
+	tags     map[string]TagFunc
+	prefixes map[string]TagPrefixFunc
+}{
+	tags:     map[string]TagFunc{},
+	prefixes: map[string]TagPrefixFunc{},
+}
+
+// RegisterTag registers a custom tag (e.g. "@tenant") to be used by the
+// FieldMap of every log middleware. It panics if the tag is invalid or
+// collides with a built-in or already registered tag.
+func RegisterTag(tag string, fn TagFunc) {
+	if len(tag) < 2 || tag[0] != '@' || strings.HasSuffix(tag, ":") || fn == nil {
+		panic(fmt.Sprintf("echo: invalid log tag %q", tag))
+	}
+
+	tagRegistry.Lock()
+	defer tagRegistry.Unlock()
+
+	if tagCollides(tag, false) {
+		panic(fmt.Sprintf("echo: log tag %q already registered", tag))
+	}
+
+	tagRegistry.tags[tag] = fn
+}
+
+// RegisterTagPrefix registers a custom tag prefix (e.g. "@claim:") to be used
+// by the FieldMap of every log middleware. It panics if the prefix is invalid
+// or collides with a built-in or already registered tag.
+func RegisterTagPrefix(prefix string, fn TagPrefixFunc) {
+	if len(prefix) < 3 || prefix[0] != '@' || !strings.HasSuffix(prefix, ":") || fn == nil {
+		panic(fmt.Sprintf("echo: invalid log tag prefix %q", prefix))
+	}
+
+	tagRegistry.Lock()
+	defer tagRegistry.Unlock()
+
+	if tagCollides(prefix, true) {
+		panic(fmt.Sprintf("echo: log tag prefix %q already registered", prefix))
+	}
+
+	tagRegistry.prefixes[prefix] = fn
+}
+
+// tagCollides checks if the tag or prefix collides with the built-in and
+// registered ones, a tag can not start with a prefix and a prefix can not
+// match the beginning of any tag or other prefix.
+func tagCollides(tag string, prefix bool) bool {
+	prefixes := slices.Clone(builtinTagPrefixes)
+	for p := range tagRegistry.prefixes {
+		prefixes = append(prefixes, p)
+	}
+
+	tags := slices.Clone(builtinTags)
+	for t := range tagRegistry.tags {
+		tags = append(tags, t)
+	}
+
+	for _, p := range prefixes {
+		if strings.HasPrefix(tag, p) || (prefix && strings.HasPrefix(p, tag)) {
+			return true
+		}
+	}
+
+	for _, t := range tags {
+		if t == tag || (prefix && strings.HasPrefix(t, tag)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// customTagValue resolves the value of registered tags and prefixes.
+func customTagValue(ec echo.Context, tag string) (interface{}, bool) {
+	tagRegistry.RLock()
+	defer tagRegistry.RUnlock()
+
+	if fn, ok := tagRegistry.tags[tag]; ok {
+		return fn(ec), true
+	}
+
+	for p, fn := range tagRegistry.prefixes {
+		if strings.HasPrefix(tag, p) {
+			return fn(ec, tag[len(p):]), true
+		}
+	}
+
+	return nil, false
+}
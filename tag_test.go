@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// cleanupTags removes the registered tags and prefixes when the test ends.
+func cleanupTags(t *testing.T, tags ...string) {
+	t.Helper()
+
+	t.Cleanup(func() {
+		tagRegistry.Lock()
+		defer tagRegistry.Unlock()
+
+		for _, tag := range tags {
+			delete(tagRegistry.tags, tag)
+			delete(tagRegistry.prefixes, tag)
+		}
+	})
+}
+
+func TestRegisterTag(t *testing.T) {
+	cleanupTags(t, "@test_tenant", "@test_claim:")
+
+	RegisterTag("@test_tenant", func(ec echo.Context) interface{} {
+		return ec.Request().Header.Get("user")
+	})
+
+	RegisterTagPrefix("@test_claim:", func(_ echo.Context, name string) interface{} {
+		return "claim-" + name
+	})
+
+	opts := logOptions{
+		fieldMap: map[string]string{
+			"tenant": "@test_tenant",
+			"sub":    "@test_claim:sub",
+		},
+	}
+
+	entry, _ := mapFields(postCtx(t), testHandler, opts)
+
+	if entry.fields["tenant"] != "admin" {
+		t.Errorf("expect custom tag value 'admin', got '%v'", entry.fields["tenant"])
+	}
+
+	if entry.fields["sub"] != "claim-sub" {
+		t.Errorf("expect custom prefix value 'claim-sub', got '%v'", entry.fields["sub"])
+	}
+}
+
+func TestRegisterTagCollision(t *testing.T) {
+	cleanupTags(t, "@test_collision", "@test_collision_prefix:", "@test_scoped:id")
+
+	RegisterTag("@test_collision", func(echo.Context) interface{} { return nil })
+	RegisterTagPrefix("@test_collision_prefix:", func(echo.Context, string) interface{} { return nil })
+	RegisterTag("@test_scoped:id", func(echo.Context) interface{} { return nil })
+
+	tests := []struct {
+		name     string
+		register func()
+	}{
+		{"builtin_tag", func() { RegisterTag(logID, func(echo.Context) interface{} { return nil }) }},
+		{"builtin_prefix", func() { RegisterTag("@header:x", func(echo.Context) interface{} { return nil }) }},
+		{"registered_tag", func() { RegisterTag("@test_collision", func(echo.Context) interface{} { return nil }) }},
+		{"registered_prefix", func() {
+			RegisterTag("@test_collision_prefix:x", func(echo.Context) interface{} { return nil })
+		}},
+		{"prefix_builtin", func() { RegisterTagPrefix(logCookiePrefix, func(echo.Context, string) interface{} { return nil }) }},
+		{"prefix_tag", func() { RegisterTagPrefix("@test_scoped:", func(echo.Context, string) interface{} { return nil }) }},
+		{"invalid_tag", func() { RegisterTag("tenant", func(echo.Context) interface{} { return nil }) }},
+		{"invalid_func", func() { RegisterTag("@test_nil", nil) }},
+		{"invalid_prefix", func() { RegisterTagPrefix("@test_prefix", func(echo.Context, string) interface{} { return nil }) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expect register to panic")
+				}
+			}()
+
+			tt.register()
+		})
+	}
+}
@@ -0,0 +1,263 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"container/list"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// User-Agent constants.
+const (
+	uaCacheSize = 1024
+	uaMaxLen    = 512
+	uaOther     = "Other"
+)
+
+// Device types of the @ua_device_type tag.
+const (
+	uaDesktop = "desktop"
+	uaMobile  = "mobile"
+	uaTablet  = "tablet"
+	uaBot     = "bot"
+)
+
+// uaTags lists the tags resolved by the User-Agent parser.
+var uaTags = []string{
+	logUABrowser, logUABrowserVer, logUAOS, logUADeviceType, logUAIsBot,
+}
+
+// uaBotProducts identifies the http clients and crawlers by product name.
+var uaBotProducts = []string{
+	"curl", "wget", "python-requests", "go-http-client", "okhttp",
+	"headlesschrome", "facebookexternalhit",
+}
+
+// uaBotWords identifies the crawlers by a word in the product name.
+var uaBotWords = []string{"crawler", "spider", "slurp", "bot-"}
+
+// uaBrowsers defines the browser tokens, the order matters as most browsers
+// include the tokens of the ones they are based on (e.g. Edge includes
+// Chrome and Safari). The version is read after the matched token, unless a
+// version token is defined.
+var uaBrowsers = []struct {
+	name    string
+	tokens  []string
+	version string
+}{
+	{"Edge", []string{"Edg/", "EdgA/", "EdgiOS/", "Edge/"}, ""},
+	{"Opera", []string{"OPR/", "Opera/"}, ""},
+	{"Samsung Internet", []string{"SamsungBrowser/"}, ""},
+	{"Firefox", []string{"Firefox/", "FxiOS/"}, ""},
+	{"Chrome", []string{"CriOS/", "Chrome/"}, ""},
+	{"Safari", []string{"Safari/"}, "Version/"},
+	{"Internet Explorer", []string{"MSIE "}, ""},
+	{"Internet Explorer", []string{"Trident/"}, "rv:"},
+}
+
+// uaOSes defines the operating system tokens, in match order.
+var uaOSes = []struct {
+	name   string
+	tokens []string
+}{
+	{"Windows", []string{"Windows"}},
+	{"Android", []string{"Android"}},
+	{"iOS", []string{"iPhone", "iPad", "iPod"}},
+	{"ChromeOS", []string{"CrOS"}},
+	{"macOS", []string{"Macintosh", "Mac OS X"}},
+	{"Linux", []string{"Linux"}},
+}
+
+// userAgent defines the client data parsed from the User-Agent header.
+type userAgent struct {
+	browser        string
+	browserVersion string
+	os             string
+	deviceType     string
+	bot            bool
+}
+
+// uaCache is the LRU cache of parsed User-Agents, shared by every log
+// middleware.
+var uaCache = newUserAgentCache(uaCacheSize)
+
+// userAgentCache stores the most recently parsed User-Agents.
+type userAgentCache struct {
+	mu    sync.Mutex
+	size  int
+	items map[string]*list.Element
+	order *list.List
+}
+
+// userAgentItem defines an entry of the User-Agent cache.
+type userAgentItem struct {
+	key string
+	ua  userAgent
+}
+
+// newUserAgentCache returns a cache holding up to size User-Agents.
+func newUserAgentCache(size int) *userAgentCache {
+	return &userAgentCache{
+		size:  size,
+		items: map[string]*list.Element{},
+		order: list.New(),
+	}
+}
+
+// get returns the parsed User-Agent, parsing and storing it on cache miss.
+// The least recently used entry is evicted when the cache is full, and only
+// the first 512 bytes are used, bounding the cache memory.
+func (c *userAgentCache) get(s string) userAgent {
+	if len(s) > uaMaxLen {
+		s = strings.Clone(s[:uaMaxLen])
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[s]; ok {
+		c.order.MoveToFront(el)
+		return el.Value.(*userAgentItem).ua
+	}
+
+	ua := parseUserAgent(s)
+	c.items[s] = c.order.PushFront(&userAgentItem{key: s, ua: ua})
+
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*userAgentItem).key)
+	}
+
+	return ua
+}
+
+// userAgentTags sets the tags of the parsed User-Agent.
+func userAgentTags(s string, tags map[string]interface{}) {
+	ua := uaCache.get(s)
+
+	tags[logUABrowser] = ua.browser
+	tags[logUABrowserVer] = ua.browserVersion
+	tags[logUAOS] = ua.os
+	tags[logUADeviceType] = ua.deviceType
+	tags[logUAIsBot] = ua.bot
+}
+
+// parseUserAgent extracts the browser, operating system and device type from
+// the User-Agent, the unknown values are reported as "Other".
+func parseUserAgent(s string) userAgent {
+	ua := userAgent{
+		browser: uaOther,
+		os:      uaOther,
+		bot:     uaIsBot(s),
+	}
+
+	for _, b := range uaBrowsers {
+		version, ok := uaTokenVersion(s, b.tokens)
+		if !ok {
+			continue
+		}
+
+		if b.version != "" {
+			version, _ = uaTokenVersion(s, []string{b.version})
+		}
+
+		ua.browser = b.name
+		ua.browserVersion = version
+
+		break
+	}
+
+	for _, o := range uaOSes {
+		if uaContains(s, o.tokens) {
+			ua.os = o.name
+			break
+		}
+	}
+
+	ua.deviceType = uaDeviceType(s, ua)
+
+	return ua
+}
+
+// uaIsBot checks if the User-Agent belongs to a bot or http client, the
+// product tokens (e.g. "Googlebot/2.1") are matched, so device names ending
+// with "bot" (e.g. "CUBOT P30") are not handled as bots.
+func uaIsBot(s string) bool {
+	products := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return r == ' ' || r == ';' || r == '(' || r == ')' || r == ','
+	})
+
+	for _, product := range products {
+		name, _, versioned := strings.Cut(product, "/")
+
+		if versioned && (strings.HasSuffix(name, "bot") || slices.Contains(uaBotProducts, name)) {
+			return true
+		}
+
+		for _, word := range uaBotWords {
+			if strings.Contains(name, word) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// uaDeviceType resolves the device type of the User-Agent.
+func uaDeviceType(s string, ua userAgent) string {
+	switch {
+	case ua.bot:
+		return uaBot
+	case uaContains(s, []string{"iPad", "Tablet"}),
+		ua.os == "Android" && !strings.Contains(s, "Mobile"):
+		return uaTablet
+	case uaContains(s, []string{"Mobi", "iPhone", "iPod"}):
+		return uaMobile
+	case ua.os != uaOther:
+		return uaDesktop
+	default:
+		return uaOther
+	}
+}
+
+// uaContains checks if the User-Agent contains any of the tokens.
+func uaContains(s string, tokens []string) bool {
+	for _, token := range tokens {
+		if strings.Contains(s, token) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// uaTokenVersion finds the first token in the User-Agent, returning the
+// major version following it.
+func uaTokenVersion(s string, tokens []string) (string, bool) {
+	for _, token := range tokens {
+		i := strings.Index(s, token)
+		if i < 0 {
+			continue
+		}
+
+		version := s[i+len(token):]
+		end := strings.IndexFunc(version, func(r rune) bool {
+			return r < '0' || r > '9'
+		})
+
+		if end >= 0 {
+			version = version[:end]
+		}
+
+		return version, true
+	}
+
+	return "", false
+}
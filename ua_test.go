@@ -0,0 +1,182 @@
+/*
+ * Copyright (c) Fabio da Silva Ribeiro <faabiosr@gmail.com>
+ * SPDX-License-Identifier: MIT
+ */
+
+package middleware
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		name string
+		ua   string
+		want userAgent
+	}{
+		{
+			"chrome windows",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.71 Safari/537.36",
+			userAgent{"Chrome", "120", "Windows", uaDesktop, false},
+		},
+		{
+			"edge windows",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.61",
+			userAgent{"Edge", "120", "Windows", uaDesktop, false},
+		},
+		{
+			"firefox linux",
+			"Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			userAgent{"Firefox", "121", "Linux", uaDesktop, false},
+		},
+		{
+			"safari iphone",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1",
+			userAgent{"Safari", "17", "iOS", uaMobile, false},
+		},
+		{
+			"safari macos",
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15",
+			userAgent{"Safari", "17", "macOS", uaDesktop, false},
+		},
+		{
+			"chrome ipad",
+			"Mozilla/5.0 (iPad; CPU OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1",
+			userAgent{"Chrome", "120", "iOS", uaTablet, false},
+		},
+		{
+			"samsung android mobile",
+			"Mozilla/5.0 (Linux; Android 13; SM-S901B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36",
+			userAgent{"Samsung Internet", "23", "Android", uaMobile, false},
+		},
+		{
+			"chrome android tablet",
+			"Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			userAgent{"Chrome", "120", "Android", uaTablet, false},
+		},
+		{
+			"internet explorer 11",
+			"Mozilla/5.0 (Windows NT 10.0; Trident/7.0; rv:11.0) like Gecko",
+			userAgent{"Internet Explorer", "11", "Windows", uaDesktop, false},
+		},
+		{
+			"googlebot",
+			"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			userAgent{uaOther, "", uaOther, uaBot, true},
+		},
+		{
+			"curl",
+			"curl/8.4.0",
+			userAgent{uaOther, "", uaOther, uaBot, true},
+		},
+		{
+			"unknown",
+			"cli-agent",
+			userAgent{uaOther, "", uaOther, uaOther, false},
+		},
+		{
+			"cubot phone",
+			"Mozilla/5.0 (Linux; Android 9; CUBOT P30) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/90.0.4430.91 Mobile Safari/537.36",
+			userAgent{"Chrome", "90", "Android", uaMobile, false},
+		},
+		{
+			"bing",
+			"Mozilla/5.0 (compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm)",
+			userAgent{uaOther, "", uaOther, uaBot, true},
+		},
+		{
+			"slack",
+			"Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)",
+			userAgent{uaOther, "", uaOther, uaBot, true},
+		},
+		{"empty", "", userAgent{uaOther, "", uaOther, uaOther, false}},
+	}
+
+	for _, tt := range tests {
+		if got := parseUserAgent(tt.ua); got != tt.want {
+			t.Errorf("invalid %s: expect '%+v', got '%+v'", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestUserAgentCache(t *testing.T) {
+	c := newUserAgentCache(2)
+
+	c.get("curl/8.4.0")
+	c.get("cli-agent")
+	c.get("curl/8.4.0")
+	c.get("Wget/1.21")
+
+	if _, ok := c.items["cli-agent"]; ok {
+		t.Errorf("expect least recently used entry to be evicted")
+	}
+
+	if _, ok := c.items["curl/8.4.0"]; !ok || c.order.Len() != 2 {
+		t.Errorf("expect recently used entries to be kept, got %d entries", c.order.Len())
+	}
+
+	if ua := c.get("Wget/1.21"); !ua.bot {
+		t.Errorf("expect cached entry, got '%+v'", ua)
+	}
+}
+
+func TestMapFieldsWithUserAgentTags(t *testing.T) {
+	opts := logOptions{
+		fieldMap: map[string]string{
+			"browser": logUABrowser,
+			"os":      logUAOS,
+			"device":  logUADeviceType,
+			"bot":     logUAIsBot,
+		},
+	}
+
+	entry, _ := mapFields(postCtx(t), testHandler, opts)
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"browser", entry.fields["browser"], uaOther},
+		{"os", entry.fields["os"], uaOther},
+		{"device", entry.fields["device"], uaOther},
+		{"bot", entry.fields["bot"], false},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("invalid %s: expect '%v', got '%v'", tt.name, tt.want, tt.got)
+		}
+	}
+}
+
+func TestTagFieldsWithoutUserAgentTags(t *testing.T) {
+	tags := map[string]interface{}{}
+	_ = tagFields(postCtx(t), map[string]string{"method": logMethod}, tags, nil)
+
+	if _, ok := tags[logUABrowser]; ok {
+		t.Errorf("expect User-Agent not to be parsed")
+	}
+}
+
+func TestUserAgentCacheKeyLimit(t *testing.T) {
+	c := newUserAgentCache(uaCacheSize)
+	long := strings.Repeat("a", 512<<10)
+
+	for i := 0; i < 5; i++ {
+		c.get(long + strconv.Itoa(i))
+	}
+
+	if c.order.Len() != 1 {
+		t.Errorf("expect long User-Agents to share the truncated key, got %d entries", c.order.Len())
+	}
+
+	for key := range c.items {
+		if len(key) > uaMaxLen {
+			t.Errorf("expect key up to %d bytes, got %d", uaMaxLen, len(key))
+		}
+	}
+}
@@ -6,9 +6,11 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	emw "github.com/labstack/echo/v4/middleware"
@@ -53,6 +55,10 @@ func TestZapLogWithConfig(t *testing.T) {
 		{"filter_name", ectx["filter_name"], "john"},
 		{"username", ectx["username"], "doejohn"},
 		{"session", ectx["session"], "A1B2C3"},
+		{"body_in", ectx["body_in"], "username=doejohn"},
+		{"body_out", ectx["body_out"], "test"},
+		{"param_id", ectx["param_id"], "456"},
+		{"res_id", ectx["res_id"], "123"},
 	}
 
 	for _, tt := range tests {
@@ -136,3 +142,237 @@ func TestZapLogRecoverFn(t *testing.T) {
 		t.Errorf("invalid log: wrong status code")
 	}
 }
+
+func TestZapLogWithLevel(t *testing.T) {
+	ec := errCtx(t)
+	logger, logs := observer.New(zap.InfoLevel)
+
+	config := ZapLogConfig{
+		Logger: zap.New(logger),
+		Level:  StatusLevel,
+	}
+
+	_ = ZapLogWithConfig(config)(testHandler)(ec)
+
+	if lvl := logs.All()[0].Level; lvl != zap.ErrorLevel {
+		t.Errorf("invalid log: expect error level, got '%s'", lvl)
+	}
+}
+
+func TestZapLogWithSampler(t *testing.T) {
+	logger, logs := observer.New(zap.InfoLevel)
+	sampler := &Sampler{KeepErrors: true}
+
+	config := ZapLogConfig{
+		Logger:  zap.New(logger),
+		Sampler: sampler,
+	}
+
+	_ = ZapLogWithConfig(config)(testHandler)(reqCtx(t))
+	_ = ZapLogWithConfig(config)(testHandler)(errCtx(t))
+
+	if logs.Len() != 1 {
+		t.Errorf("expect only the error request logged, got '%d' entries", logs.Len())
+	}
+
+	if sampler.Dropped() != 1 {
+		t.Errorf("expect 1 dropped entry, got '%d'", sampler.Dropped())
+	}
+}
+
+func TestZapLogWithTypedValues(t *testing.T) {
+	ec := postCtx(t)
+	logger, logs := observer.New(zap.InfoLevel)
+
+	config := ZapLogConfig{
+		Logger:      zap.New(logger),
+		FieldMap:    testFields,
+		TypedValues: true,
+		LatencyUnit: LatencyDuration,
+	}
+
+	_ = ZapLogWithConfig(config)(testHandler)(ec)
+
+	ectx := logs.All()[0].ContextMap()
+
+	if _, ok := ectx["latency"].(time.Duration); !ok {
+		t.Errorf("expect latency as duration, got '%T'", ectx["latency"])
+	}
+
+	if ectx["bytes_out"] != int64(4) {
+		t.Errorf("expect bytes_out as int64, got '%T'", ectx["bytes_out"])
+	}
+}
+
+func TestZapLogWithNestedFields(t *testing.T) {
+	ec := postCtx(t)
+	logger, logs := observer.New(zap.InfoLevel)
+
+	config := ZapLogConfig{
+		Logger: zap.New(logger),
+		FieldMap: map[string]string{
+			"http.request.method": logMethod,
+			"url.path":            logPath,
+		},
+		NestedFields: true,
+	}
+
+	_ = ZapLogWithConfig(config)(testHandler)(ec)
+
+	ectx := logs.All()[0].ContextMap()
+
+	urlFields, _ := ectx["url"].(map[string]interface{})
+	if urlFields["path"] != "/foo/456" {
+		t.Errorf("invalid log: expect nested url path, got '%v'", ectx["url"])
+	}
+
+	httpFields, _ := ectx["http"].(map[string]interface{})
+	request, _ := httpFields["request"].(map[string]interface{})
+
+	if request["method"] != "POST" {
+		t.Errorf("invalid log: expect nested method, got '%v'", ectx["http"])
+	}
+}
+
+func TestZapLogWithContextLogger(t *testing.T) {
+	ec := postCtx(t)
+	logger, logs := observer.New(zap.InfoLevel)
+
+	config := ZapLogConfig{
+		Logger:          zap.New(logger),
+		ContextFieldMap: RequestContextFieldMap,
+	}
+
+	_ = ZapLogWithConfig(config)(func(ec echo.Context) error {
+		ZapLogFromContext(ec.Request().Context()).Info("handler")
+		return testHandler(ec)
+	})(ec)
+
+	ectx := logs.All()[0].ContextMap()
+
+	if ectx["id"] != "123" || ectx["route"] != "/foo/:id" {
+		t.Errorf("invalid log: expect request fields, got '%v'", ectx)
+	}
+}
+
+func TestZapLogFromContextFallback(t *testing.T) {
+	if ZapLogFromContext(context.Background()) != DefaultZapLogConfig.Logger {
+		t.Errorf("expect default config logger")
+	}
+}
+
+func TestZapLogWithMultiValues(t *testing.T) {
+	logger, logs := observer.New(zap.InfoLevel)
+
+	config := ZapLogConfig{
+		Logger: zap.New(logger),
+		FieldMap: map[string]string{
+			"ids": logQueriesPrefix + "id",
+		},
+	}
+
+	_ = ZapLogWithConfig(config)(testHandler)(multiValueCtx(t))
+
+	ids, ok := logs.All()[0].ContextMap()["ids"].([]interface{})
+	if !ok || len(ids) != 2 {
+		t.Errorf("invalid log: expect ids as array, got '%v'", logs.All()[0].ContextMap()["ids"])
+	}
+}
+
+func TestZapLogWithProcessors(t *testing.T) {
+	ec := postCtx(t)
+	logger, logs := observer.New(zap.InfoLevel)
+
+	config := ZapLogConfig{
+		Logger:   zap.New(logger),
+		FieldMap: map[string]string{"path": logPath},
+		Processors: []FieldProcessor{
+			func(_ echo.Context, fields Fields) Fields {
+				fields["version"] = "1.0.0"
+				return fields
+			},
+		},
+	}
+
+	_ = ZapLogWithConfig(config)(testHandler)(ec)
+
+	if ectx := logs.All()[0].ContextMap(); ectx["version"] != "1.0.0" {
+		t.Errorf("invalid log: expect version field, got '%v'", ectx)
+	}
+}
+
+func TestZapLogWithRecover(t *testing.T) {
+	ec := panicCtx(t)
+	logger, logs := observer.New(zap.InfoLevel)
+
+	config := ZapLogConfig{
+		Logger:  zap.New(logger),
+		Recover: true,
+	}
+
+	if err := ZapLogWithConfig(config)(testHandler)(ec); err != nil {
+		t.Errorf("invalid log: expect panic to be handled, got '%v'", err)
+	}
+
+	entry := logs.All()[0]
+	if entry.Level != zap.ErrorLevel || entry.Message != recoverMessage {
+		t.Errorf("invalid log: expect panic entry, got '%v'", entry.Entry)
+	}
+
+	if _, ok := entry.ContextMap()[recoverStackField]; !ok {
+		t.Errorf("invalid log: expect stacktrace field")
+	}
+}
+
+func TestZapLogWithSlowRequest(t *testing.T) {
+	ec := reqCtx(t)
+	logger, logs := observer.New(zap.InfoLevel)
+
+	config := ZapLogConfig{
+		Logger:      zap.New(logger),
+		SlowRequest: &SlowRequest{Threshold: time.Millisecond},
+	}
+
+	_ = ZapLogWithConfig(config)(slowHandler)(ec)
+
+	entry := logs.All()[0]
+	if entry.Level != zap.WarnLevel || entry.Message != slowMessage {
+		t.Errorf("invalid log: expect slow request entry, got '%v'", entry.Entry)
+	}
+}
+
+func TestZapLogWithErrorPolicy(t *testing.T) {
+	logger, _ := observer.New(zap.InfoLevel)
+
+	testErrorPolicies(t, func(p ErrorPolicy) echo.MiddlewareFunc {
+		return ZapLogWithConfig(ZapLogConfig{Logger: zap.New(logger), ErrorPolicy: p})
+	})
+}
+
+func TestZapLogWithConfiguredContextLogger(t *testing.T) {
+	ec := postCtx(t)
+	logger := zap.New(nil)
+
+	_ = ZapLogWithConfig(ZapLogConfig{Logger: logger})(func(ec echo.Context) error {
+		if ZapLogFromContext(ec.Request().Context()) != logger {
+			t.Errorf("invalid log: expect configured logger")
+		}
+
+		return testHandler(ec)
+	})(ec)
+}
+
+func TestZapLogRecoverFnWithMessage(t *testing.T) {
+	ec := panicCtx(t)
+	obsLog, logs := observer.New(zap.InfoLevel)
+
+	rec := emw.RecoverWithConfig(emw.RecoverConfig{
+		LogErrorFunc: ZapLogRecoverFnWithMessage(zap.New(obsLog), "{method} {path}"),
+	})
+
+	_ = rec(testHandler)(ec)
+
+	if msg := logs.All()[0].Message; msg != "panic recover: GET /some" {
+		t.Errorf("invalid log: expect templated message, got '%s'", msg)
+	}
+}
@@ -6,6 +6,10 @@
 package middleware
 
 import (
+	"context"
+	"sort"
+	"time"
+
 	"github.com/labstack/echo/v4"
 	mw "github.com/labstack/echo/v4/middleware"
 	"github.com/rs/zerolog"
@@ -19,7 +23,9 @@ type ZeroLogConfig struct {
 	// Tags to constructed the logger fields.
 	//
 	// - @id (Request ID)
-	// - @remote_ip
+	// - @remote_ip (Client IP, see IPExtractor)
+	// - @peer_ip (Connection peer IP)
+	// - @forwarded_chain (Proxy hops of Forwarded or X-Forwarded-For)
 	// - @uri
 	// - @host
 	// - @method
@@ -28,18 +34,131 @@ type ZeroLogConfig struct {
 	// - @protocol
 	// - @referer
 	// - @user_agent
+	// - @ua_browser (Browser name parsed from the User-Agent)
+	// - @ua_browser_version (Browser major version)
+	// - @ua_os (Operating system name)
+	// - @ua_device_type (desktop, mobile, tablet, bot or Other)
+	// - @ua_is_bot (Crawlers, monitors and http clients)
 	// - @status
 	// - @error
-	// - @latency (In nanoseconds)
+	// - @error_code (HTTP error code)
+	// - @error_message (HTTP error message or error string)
+	// - @error_internal (Internal error of HTTP errors)
+	// - @error_type (Go type of the error)
+	// - @error_chain (Messages of the wrapped errors)
+	// - @error_stack (Stack trace, when the error provides one)
+	// - @latency (In nanoseconds, see LatencyUnit for typed values)
 	// - @latency_human (Human readable)
 	// - @bytes_in (Bytes received)
 	// - @bytes_out (Bytes sent)
+	// - @body_in (Request body, see RequestBody)
+	// - @body_out (Response body, see ResponseBody)
+	// - @ttfb (Time to first byte, in nanoseconds)
+	// - @write_duration (Time spent writing the response, in nanoseconds)
+	// - @flush_count (Number of response flushes)
+	// - @start_time (Request start time, see TimeFormat)
+	// - @end_time (Request end time, see TimeFormat)
 	// - @header:<NAME>
 	// - @query:<NAME>
 	// - @form:<NAME>
 	// - @cookie:<NAME>
+	// - @header[]:<NAME> (All values of the header)
+	// - @query[]:<NAME> (All values of the query param)
+	// - @form[]:<NAME> (All values of the form field)
+	// - @param:<NAME> (Path parameter)
+	// - @ctx:<KEY> (Value stored with echo.Context Set)
+	// - @res_header:<NAME> (Response header)
+	//
+	// Custom tags can be added with `RegisterTag()` and `RegisterTagPrefix()`.
 	FieldMap map[string]string
 
+	// ContextFieldMap set a list of fields with tags added to the logger
+	// stored in the request context, see `ZeroLogFromContext()`. When it is
+	// empty the Logger is stored as is (e.g. RequestContextFieldMap).
+	ContextFieldMap map[string]string
+
+	// RequestBody defines how the request body is captured by the @body_in
+	// tag.
+	RequestBody BodyConfig
+
+	// ResponseBody defines how the response body is captured by the
+	// @body_out tag.
+	ResponseBody BodyConfig
+
+	// Redactor masks sensitive values of headers, query params, form fields
+	// and cookies, including the query params of @uri and @referer.
+	Redactor *Redactor
+
+	// Sampler defines which requests are logged, by default all requests are
+	// logged.
+	Sampler *Sampler
+
+	// TypedValues logs @latency, @bytes_in and @bytes_out as numbers instead
+	// of strings.
+	TypedValues bool
+
+	// LatencyUnit defines the unit of @latency when TypedValues is enabled.
+	// Default: LatencyNanoseconds.
+	LatencyUnit LatencyUnit
+
+	// NestedFields converts dotted FieldMap keys (e.g. "http.request.method")
+	// into nested objects.
+	NestedFields bool
+
+	// TimeFormat defines the format of @start_time and @end_time, a time
+	// layout or one of the TimeFormatUnix constants.
+	// Default: time.RFC3339Nano.
+	TimeFormat string
+
+	// TimeUTC converts @start_time and @end_time to UTC, otherwise the local
+	// time is used.
+	TimeUTC bool
+
+	// Async emits the log entries in background workers, by default they are
+	// emitted synchronously.
+	Async *AsyncLogger
+
+	// Processors defines a chain of functions applied on the log fields
+	// before the entry is emitted.
+	Processors []FieldProcessor
+
+	// Recover recovers from panics in the handler chain, emitting a single
+	// error entry with the FieldMap fields and the stacktrace. The 500
+	// response is sent by the echo error handler.
+	Recover bool
+
+	// SlowRequest logs the requests exceeding a latency threshold with the
+	// "slow request" message and an elevated level.
+	SlowRequest *SlowRequest
+
+	// LogStart emits a "request started" entry before calling the handler,
+	// with the FieldMap tags known at that point (e.g. @id, @method, @uri).
+	// The response, error, form and body tags are not logged, and the
+	// entry is only emitted when the request is kept by the Sampler rate.
+	LogStart bool
+
+	// Message defines a template of the request entry message, the
+	// placeholders are tag names without "@" (e.g. "{method} {route}
+	// {status} in {latency_human}"). Default: "handle request".
+	Message string
+
+	// MessageFunc builds the request entry message from the log fields, it
+	// has precedence over Message.
+	MessageFunc MessageFunc
+
+	// ErrorPolicy defines how the handler error is handled and propagated.
+	// Default: ErrorHandleAndPropagate.
+	ErrorPolicy ErrorPolicy
+
+	// IPExtractor defines how the client IP of @remote_ip is extracted
+	// (e.g. `echo.ExtractIPDirect()`, `ExtractIPFromForwardedHeader()`), by
+	// default the echo.Context RealIP is used.
+	IPExtractor echo.IPExtractor
+
+	// Level defines a function to resolve the log level of each request,
+	// when it is not defined the requests are logged as info.
+	Level LevelFunc
+
 	// Logger it is a zerolog logger
 	Logger zerolog.Logger
 
@@ -47,6 +166,9 @@ type ZeroLogConfig struct {
 	Skipper mw.Skipper
 }
 
+// zeroLogKey key used to store the request-scoped logger in context.
+var zeroLogKey = &ctxkey{"zerolog-logger"}
+
 // DefaultZeroLogConfig is the default ZeroLog middleware config.
 var DefaultZeroLogConfig = ZeroLogConfig{
 	FieldMap: defaultFields,
@@ -71,17 +193,55 @@ func ZeroLogWithConfig(cfg ZeroLogConfig) echo.MiddlewareFunc {
 		cfg.FieldMap = DefaultZeroLogConfig.FieldMap
 	}
 
+	opts := logOptions{
+		fieldMap:     cfg.FieldMap,
+		level:        cfg.Level,
+		requestBody:  cfg.RequestBody,
+		responseBody: cfg.ResponseBody,
+		redactor:     cfg.Redactor,
+		sampler:      cfg.Sampler,
+		typedValues:  cfg.TypedValues,
+		latencyUnit:  cfg.LatencyUnit,
+		nestedFields: cfg.NestedFields,
+		timeFormat:   cfg.TimeFormat,
+		timeUTC:      cfg.TimeUTC,
+		processors:   cfg.Processors,
+		recoverPanic: cfg.Recover,
+		slowRequest:  cfg.SlowRequest,
+		messageTmpl:  newMessageTemplate(cfg.Message),
+		messageFunc:  cfg.MessageFunc,
+		errorPolicy:  cfg.ErrorPolicy,
+		ipExtractor:  cfg.IPExtractor,
+	}
+
+	if cfg.LogStart {
+		opts.logStart = func(_ echo.Context, start logEntry) {
+			cfg.Async.emit(func() {
+				zeroLogEntry(cfg.Logger, start)
+			})
+		}
+	}
+
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ec echo.Context) (err error) {
 			if cfg.Skipper(ec) {
 				return next(ec)
 			}
 
-			logFields, err := mapFields(ec, next, cfg.FieldMap)
+			logger := cfg.Logger
+			if len(cfg.ContextFieldMap) > 0 {
+				fields := requestFields(ec, cfg.ContextFieldMap, opts)
+				logger = logger.With().Fields(fields).Logger()
+			}
+
+			withContextValue(ec, zeroLogKey, logger)
 
-			cfg.Logger.Info().
-				Fields(logFields).
-				Msg("handle request")
+			entry, err := mapFields(ec, next, opts)
+			if !entry.skip {
+				cfg.Async.emit(func() {
+					zeroLogEntry(cfg.Logger, entry)
+				})
+			}
 
 			return
 		}
@@ -91,14 +251,97 @@ func ZeroLogWithConfig(cfg ZeroLogConfig) echo.MiddlewareFunc {
 // ZeroLogRecoverFn returns a ZeroLog recover log function to print panic
 // errors.
 func ZeroLogRecoverFn(logger zerolog.Logger) mw.LogErrorFunc {
-	return func(_ echo.Context, err error, stack []byte) error {
+	return ZeroLogRecoverFnWithMessage(logger, "")
+}
+
+// ZeroLogRecoverFnWithMessage returns a ZeroLog recover log function to print
+// panic errors with the message template appended to "panic recover".
+//
+// The template uses the placeholders of the Message config (e.g. "{method}
+// {uri}"), the response tags are not resolved at this point.
+func ZeroLogRecoverFnWithMessage(logger zerolog.Logger, tmpl string) mw.LogErrorFunc {
+	message := recoverFnMessage(tmpl)
+
+	return func(ec echo.Context, err error, stack []byte) error {
 		logger.Error().
 			Err(err).
 			Fields(map[string]interface{}{
-				"stacktrace": stack,
+				recoverStackField: stack,
 			}).
-			Msg("panic recover")
+			Msg(message(ec))
 
 		return err
 	}
 }
+
+// ZeroLogFromContext returns the request-scoped logger stored by ZeroLog
+// middleware, otherwise returns the default config logger (e.g. outside
+// the middleware chain).
+func ZeroLogFromContext(ctx context.Context) zerolog.Logger {
+	if logger, ok := ctx.Value(zeroLogKey).(zerolog.Logger); ok {
+		return logger
+	}
+
+	return DefaultZeroLogConfig.Logger
+}
+
+// zeroLogEntry emits the log entry using zerolog logger.
+func zeroLogEntry(logger zerolog.Logger, entry logEntry) {
+	event := logger.WithLevel(zeroLogLevel(entry.level))
+
+	keys := make([]string, 0, len(entry.fields))
+	for k := range entry.fields {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		event = zeroLogField(event, k, entry.fields[k])
+	}
+
+	event.Msg(entry.message)
+}
+
+// zeroLogField adds the value into the event as a typed field.
+func zeroLogField(event *zerolog.Event, k string, v interface{}) *zerolog.Event {
+	switch val := v.(type) {
+	case string:
+		return event.Str(k, val)
+	case []string:
+		return event.Strs(k, val)
+	case int:
+		return event.Int(k, val)
+	case int64:
+		return event.Int64(k, val)
+	case float64:
+		return event.Float64(k, val)
+	case time.Duration:
+		return event.Dur(k, val)
+	case error:
+		return event.AnErr(k, val)
+	case map[string]interface{}:
+		dict := zerolog.Dict()
+		for dk, dv := range val {
+			dict = zeroLogField(dict, dk, dv)
+		}
+
+		return event.Dict(k, dict)
+	default:
+		return event.Interface(k, v)
+	}
+}
+
+// zeroLogLevel converts the log level into zerolog level.
+func zeroLogLevel(lvl Level) zerolog.Level {
+	switch lvl {
+	case LevelDebug:
+		return zerolog.DebugLevel
+	case LevelWarn:
+		return zerolog.WarnLevel
+	case LevelError:
+		return zerolog.ErrorLevel
+	default:
+		return zerolog.InfoLevel
+	}
+}
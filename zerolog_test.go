@@ -7,6 +7,8 @@ package middleware
 
 import (
 	"bytes"
+	"context"
+	"io"
 	"strings"
 	"testing"
 
@@ -129,3 +131,153 @@ func TestZeroLogRecoverFn(t *testing.T) {
 		t.Errorf("invalid log: error not found")
 	}
 }
+
+func TestZeroLogWithLevel(t *testing.T) {
+	ec := errCtx(t)
+	b := new(bytes.Buffer)
+	logger := log.Output(zerolog.ConsoleWriter{Out: b, NoColor: true})
+
+	config := ZeroLogConfig{
+		Logger: logger,
+		Level:  StatusLevel,
+	}
+
+	_ = ZeroLogWithConfig(config)(testHandler)(ec)
+
+	if !strings.Contains(b.String(), "ERR") {
+		t.Errorf("invalid log: expect error level")
+	}
+}
+
+func TestZeroLogWithTypedValues(t *testing.T) {
+	ec := postCtx(t)
+	b := new(bytes.Buffer)
+
+	config := ZeroLogConfig{
+		Logger:      zerolog.New(b),
+		FieldMap:    testFields,
+		TypedValues: true,
+	}
+
+	_ = ZeroLogWithConfig(config)(testHandler)(ec)
+
+	res := b.String()
+
+	if !strings.Contains(res, `"bytes_out":4`) {
+		t.Errorf("invalid log: expect bytes_out as number")
+	}
+
+	if !strings.Contains(res, `"status":200`) {
+		t.Errorf("invalid log: expect status as number")
+	}
+}
+
+func TestZeroLogWithNestedFields(t *testing.T) {
+	ec := postCtx(t)
+	b := new(bytes.Buffer)
+
+	config := ZeroLogConfig{
+		Logger: zerolog.New(b),
+		FieldMap: map[string]string{
+			"http.request.method": logMethod,
+		},
+		NestedFields: true,
+	}
+
+	_ = ZeroLogWithConfig(config)(testHandler)(ec)
+
+	if !strings.Contains(b.String(), `"http":{"request":{"method":"POST"}}`) {
+		t.Errorf("invalid log: expect nested fields, got '%s'", b.String())
+	}
+}
+
+func TestZeroLogWithContextLogger(t *testing.T) {
+	ec := postCtx(t)
+	b := new(bytes.Buffer)
+
+	config := ZeroLogConfig{
+		Logger:          zerolog.New(b),
+		ContextFieldMap: RequestContextFieldMap,
+	}
+
+	_ = ZeroLogWithConfig(config)(func(ec echo.Context) error {
+		logger := ZeroLogFromContext(ec.Request().Context())
+		logger.Info().Msg("handler")
+
+		return testHandler(ec)
+	})(ec)
+
+	line := strings.Split(b.String(), "\n")[0]
+
+	if !strings.Contains(line, `"id":"123"`) || !strings.Contains(line, `"route":"/foo/:id"`) {
+		t.Errorf("invalid log: expect request fields, got '%s'", line)
+	}
+}
+
+func TestZeroLogFromContextFallback(t *testing.T) {
+	logger := ZeroLogFromContext(context.Background())
+
+	if logger.GetLevel() != DefaultZeroLogConfig.Logger.GetLevel() {
+		t.Errorf("expect default config logger")
+	}
+}
+
+func TestZeroLogWithMultiValues(t *testing.T) {
+	b := new(bytes.Buffer)
+
+	config := ZeroLogConfig{
+		Logger: zerolog.New(b),
+		FieldMap: map[string]string{
+			"ids": logQueriesPrefix + "id",
+		},
+	}
+
+	_ = ZeroLogWithConfig(config)(testHandler)(multiValueCtx(t))
+
+	if !strings.Contains(b.String(), `"ids":["1","2"]`) {
+		t.Errorf("invalid log: expect ids as array, got '%s'", b.String())
+	}
+}
+
+func TestZeroLogWithRecover(t *testing.T) {
+	ec := panicCtx(t)
+	b := new(bytes.Buffer)
+	logger := log.Output(zerolog.ConsoleWriter{Out: b, NoColor: true})
+
+	config := ZeroLogConfig{
+		Logger:  logger,
+		Recover: true,
+	}
+
+	_ = ZeroLogWithConfig(config)(testHandler)(ec)
+
+	for _, s := range []string{"ERR", recoverMessage, recoverStackField} {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("invalid log: expect '%s'", s)
+		}
+	}
+}
+
+func TestZeroLogWithErrorPolicy(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+
+	testErrorPolicies(t, func(p ErrorPolicy) echo.MiddlewareFunc {
+		return ZeroLogWithConfig(ZeroLogConfig{Logger: logger, ErrorPolicy: p})
+	})
+}
+
+func TestZeroLogWithConfiguredContextLogger(t *testing.T) {
+	ec := postCtx(t)
+	b := new(bytes.Buffer)
+
+	_ = ZeroLogWithConfig(ZeroLogConfig{Logger: zerolog.New(b)})(func(ec echo.Context) error {
+		logger := ZeroLogFromContext(ec.Request().Context())
+		logger.Info().Msg("handler")
+
+		return testHandler(ec)
+	})(ec)
+
+	if line := strings.Split(b.String(), "\n")[0]; !strings.Contains(line, `"message":"handler"`) {
+		t.Errorf("invalid log: expect configured logger, got '%s'", line)
+	}
+}